@@ -0,0 +1,91 @@
+package data
+
+import (
+	"regexp"
+	"strings"
+	"sylmark/lsp"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// ^block-id at end of line, preceded by space unless it is alone on it's line
+var blockIdRegex = regexp.MustCompile(`(?:^|\s)(\^[A-Za-z0-9][A-Za-z0-9-]*)\s*$`)
+
+type blockId struct {
+	subTarget SubTarget
+	// from start of the block till end of the marker
+	rng lsp.Range
+}
+
+func IsBlockSubTarget(subTarget SubTarget) bool {
+	return strings.HasPrefix(string(subTarget), "#^")
+}
+
+// paragraph, list item, quote or table having row, nil within code
+func getBlockNode(root *tree_sitter.Node, row uint, column uint) *tree_sitter.Node {
+	point := tree_sitter.Point{Row: row, Column: column}
+	for n := root.NamedDescendantForPointRange(point, point); n != nil; n = n.Parent() {
+		switch n.Kind() {
+		case "fenced_code_block", "indented_code_block", "html_block", "minus_metadata", "plus_metadata":
+			return nil
+		case "paragraph", "list_item", "block_quote", "pipe_table":
+			return n
+		}
+	}
+	return nil
+}
+
+// an id alone after a table or quote marks the block above it as in obsidian
+func getBlockIds(content string, trees *lsp.Trees, span lineSpan) (ids []blockId) {
+	root := trees.GetMainTree().RootNode()
+	forLinesWithin(content, span, func(i int, line string) {
+		line = strings.TrimRight(line, "\r")
+		match := blockIdRegex.FindStringSubmatchIndex(line)
+		if match == nil {
+			return
+		}
+		markerStart, markerEnd := match[2], match[3]
+		block := getBlockNode(root, uint(i), uint(markerStart))
+		if block == nil {
+			return
+		}
+		start := block.StartPosition()
+		isAlone := len(strings.TrimSpace(line[:markerStart])) == 0
+		if isAlone && start.Row == uint(i) {
+			prev := block.PrevNamedSibling()
+			if prev == nil {
+				return
+			}
+			start = prev.StartPosition()
+		}
+		ids = append(ids, blockId{
+			subTarget: SubTarget("#" + line[markerStart:markerEnd]),
+			rng: lsp.Range{
+				Start: lsp.Position{Line: int(start.Row), Character: int(start.Column)},
+				End:   lsp.Position{Line: i, Character: markerEnd},
+			},
+		})
+	})
+	return ids
+}
+
+// block id whose ^marker is under pos and it's range
+func (s *Store) GetBlockIdAt(id Id, pos lsp.Position) (subTarget SubTarget, rng lsp.Range, ok bool) {
+	doc, found := s.GetDoc(id)
+	if !found {
+		return "", rng, false
+	}
+	line := strings.TrimRight(doc.Content.GetLine(pos.Line), "\r")
+	match := blockIdRegex.FindStringSubmatchIndex(line)
+	if match == nil || pos.Character < match[2] || pos.Character > match[3] {
+		return "", rng, false
+	}
+	subTarget = SubTarget("#" + line[match[2]:match[3]])
+	if _, found := s.LinkStore.GetDef(id, subTarget); !found {
+		return "", rng, false
+	}
+	return subTarget, lsp.Range{
+		Start: lsp.Position{Line: pos.Line, Character: match[2]},
+		End:   lsp.Position{Line: pos.Line, Character: match[3]},
+	}, true
+}
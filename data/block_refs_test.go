@@ -0,0 +1,103 @@
+package data
+
+import (
+	"slices"
+	"sylmark/lsp"
+	"testing"
+)
+
+func TestBlockIdRegex(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"1 After text", "some text ^id-1", "^id-1"},
+		{"2 Alone on line", "^id", "^id"},
+		{"3 Trailing spaces", "some text ^id  ", "^id"},
+		{"4 Without space before", "some text^id", ""},
+		{"5 Not at end", "some ^id text", ""},
+		{"6 Starting with dash", "some text ^-id", ""},
+		{"7 Other characters", "some text ^id_1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if match := blockIdRegex.FindStringSubmatch(tt.line); match != nil {
+				got = match[1]
+			}
+			if got != tt.want {
+				t.Errorf("Marker >>> [%s] got [%s]", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetBlockIds(t *testing.T) {
+	parse := newTestParse(t)
+	tests := []struct {
+		name    string
+		content string
+		want    []blockId
+	}{
+		{"1 Paragraph", "# A\n\nfirst\nsecond ^p\n", []blockId{
+			{"#^p", lsp.Range{Start: lsp.Position{Line: 2}, End: lsp.Position{Line: 3, Character: 9}}},
+		}},
+		{"2 Text of list item", "- one ^one\n- two\n", []blockId{
+			{"#^one", lsp.Range{Start: lsp.Position{Character: 2}, End: lsp.Position{Character: 10}}},
+		}},
+		{"3 Alone under table", "| a | b |\n| - | - |\n| 1 | 2 |\n\n^tbl\n", []blockId{
+			{"#^tbl", lsp.Range{End: lsp.Position{Line: 4, Character: 4}}},
+		}},
+		{"4 Alone under quote", "> quoted\n\n^q\n", []blockId{
+			{"#^q", lsp.Range{End: lsp.Position{Line: 2, Character: 2}}},
+		}},
+		{"5 Alone at start of note", "^first\n", nil},
+		{"6 Within fenced code", "```\ncode ^no\n```\n", nil},
+		{"7 Within indented code", "text\n\n    code ^no\n", nil},
+		{"8 Within front matter", "---\ntitle: x ^no\n---\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trees := parse(tt.content, nil)
+			defer trees.Close()
+			if got := getBlockIds(tt.content, trees, allLines); !slices.Equal(got, tt.want) {
+				t.Errorf("Block ids >>> %v got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBlockIdCompletions(t *testing.T) {
+	s, parse := newTestVault(t)
+	b := addTestNote(t, s, parse, "b.md", "# B\n\n## Part\ntext ^blk\n\n- item ^item\n")
+	s.OpenDoc(b, "# B\n\n## Part\ntext ^blk\n\n- item ^item\n", parse)
+	labels := func(completions []lsp.CompletionItem) []string {
+		var labels []string
+		for _, c := range completions {
+			labels = append(labels, c.TextEdit.NewText)
+		}
+		slices.Sort(labels)
+		return labels
+	}
+
+	t.Run("1 Only block ids after #^ of other note", func(t *testing.T) {
+		want := []string{"[[b#^blk]]", "[[b#^item]]"}
+		if got := labels(s.GetWikiCompletions("b#^", true, false, lsp.Range{}, b)); !slices.Equal(got, want) {
+			t.Errorf("Completions >>> %v got %v", want, got)
+		}
+	})
+	t.Run("2 Only block ids after #^ within note", func(t *testing.T) {
+		want := []string{"[[#^blk", "[[#^item"}
+		if got := labels(s.GetWikiCompletions("#^", false, false, lsp.Range{}, b)); !slices.Equal(got, want) {
+			t.Errorf("Completions >>> %v got %v", want, got)
+		}
+	})
+	t.Run("3 No block ids after #", func(t *testing.T) {
+		for _, label := range labels(s.GetWikiCompletions("#", true, false, lsp.Range{}, b)) {
+			if label == "[[#^blk]]" || label == "[[#^item]]" {
+				t.Errorf("Block id %s should not be offered", label)
+			}
+		}
+	})
+}
@@ -1,18 +1,19 @@
 package data
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"path/filepath"
 	"sylmark/lsp"
 )
 
-func (store *Store) GetCodeActions(id Id, diagnostics []lsp.Diagnostic, rng lsp.Range, parse lsp.ParseFunction) (actions []lsp.CodeAction) {
+func (store *Store) GetCodeActions(ctx context.Context, id Id, diagnostics []lsp.Diagnostic, rng lsp.Range, parse lsp.ParseFunction) (actions []lsp.CodeAction) {
 	if store == nil {
 		slog.Error("DocumentStore not defined")
 		return
 	}
-	s := *store
+	s := store
 
 	doc, ok := s.GetDocMustTree(id, parse)
 	if !ok {
@@ -29,7 +30,7 @@ func (store *Store) GetCodeActions(id Id, diagnostics []lsp.Diagnostic, rng lsp.
 			return
 		}
 
-		defIdLocs, ok := s.GetDefsFromTarget(target, subTarget)
+		defIdLocs, ok := s.GetDefsFromTarget(ctx, target, subTarget)
 
 		if len(defIdLocs) != 0 {
 			return
@@ -43,11 +44,11 @@ func (store *Store) GetCodeActions(id Id, diagnostics []lsp.Diagnostic, rng lsp.
 		fileName := target.GetFileName()
 		fileUri := filepath.Join(dir, fileName)
 		if isSubTarget {
-			ids := s.getIds(target)
+			ids, _ := s.getValidIds(target)
 			title := "Append heading"
 			Title := fmt.Sprintf("Append heading `%s` in `%s`", subTarget, fileName)
 			var fileUris []string
-			if ids[0] == 0 {
+			if len(ids) == 0 {
 				// append heading only need
 				title = "Create file and append heading"
 				Title = fmt.Sprintf("Create file and Append heading `%s` in `%s`", subTarget, fileName)
@@ -0,0 +1,94 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sylmark/lsp"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// carried by file and heading completion items, rest is filled in ResolveCompletionItem
+type CompletionData struct {
+	URI       lsp.DocumentURI `json:"uri"`
+	SubTarget SubTarget       `json:"subTarget,omitempty"`
+}
+
+func (s *Store) getCompletionData(id Id, subTarget SubTarget) *CompletionData {
+	uri, ok := s.GetUri(id)
+	if !ok {
+		return nil
+	}
+	return &CompletionData{URI: uri, SubTarget: subTarget}
+}
+
+// headings leading to the one at rng like `Note > Month > Week`
+func (s *Store) getHeadingPath(id Id, rng lsp.Range, parse lsp.ParseFunction) []string {
+	uri, _ := s.GetUri(id)
+	target, _ := GetTarget(uri)
+	path := []string{string(target)}
+	docData, ok := s.GetDocMustTree(id, parse)
+	if !ok {
+		return path
+	}
+	content := string(docData.Content)
+
+	type heading struct {
+		level int
+		text  string
+	}
+	var stack []heading
+	found := false
+	lsp.TraverseNodeWith(docData.Trees.GetMainTree().RootNode(), func(n *tree_sitter.Node) {
+		if found || n.Kind() != "atx_heading" {
+			return
+		}
+		text, _, ok := GetHeadingContent(n, content)
+		level := GetHeadingLevel(n)
+		if !ok || level == 0 {
+			return
+		}
+		for len(stack) > 0 && stack[len(stack)-1].level >= level {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, heading{level: level, text: text})
+		found = int(n.StartPosition().Row) == rng.Start.Line
+	})
+	if !found {
+		return path
+	}
+	for _, h := range stack {
+		path = append(path, h.text)
+	}
+	return path
+}
+
+// fills heading path, backlink count and excerpt of item from it's CompletionData
+func (s *Store) ResolveCompletionItem(ctx context.Context, item lsp.CompletionItem, completionData CompletionData, parse lsp.ParseFunction) lsp.CompletionItem {
+	uri, _ := CleanUpURI(string(completionData.URI))
+	id, found := s.FindIdFromURI(uri)
+	if !found {
+		return item
+	}
+	subTarget := completionData.SubTarget
+
+	var rng lsp.Range
+	if len(subTarget) > 0 {
+		def, ok := s.LinkStore.GetDef(id, subTarget)
+		if !ok {
+			return item
+		}
+		rng = def
+		item.Detail = strings.Join(s.getHeadingPath(id, rng, parse), " > ")
+	} else if relPath, err := s.GetPathRelRoot(uri); err == nil {
+		item.Detail = relPath
+	}
+
+	refs := len(s.GetReferenceLocations(ctx, id, subTarget, parse))
+	item.Documentation = lsp.MarkupContent{
+		Kind:  lsp.MarkupKindMarkdown,
+		Value: fmt.Sprintf("%d references\n%s", refs, s.GetExcerpt(id, rng)),
+	}
+	return item
+}
@@ -0,0 +1,76 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sylmark/lsp"
+	"testing"
+)
+
+func TestResolveCompletionItem(t *testing.T) {
+	s, parse := newTestVault(t)
+	addTestNote(t, s, parse, "b.md", "# B\n\n## Part\ntext under part\n")
+	a := addTestNote(t, s, parse, "a.md", "[p](b.md#Part)\n")
+	aUri := testNoteURI(s, "a.md")
+	// data like client sends it back
+	resolve := func(t *testing.T, item lsp.CompletionItem) lsp.CompletionItem {
+		raw, err := json.Marshal(item.Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var completionData CompletionData
+		if err := json.Unmarshal(raw, &completionData); err != nil {
+			t.Fatal(err)
+		}
+		return s.ResolveCompletionItem(context.Background(), item, completionData, parse)
+	}
+
+	items := s.GetInlineLinkCompletions("b.md#", "p", lsp.Range{}, &aUri)
+	var item lsp.CompletionItem
+	for _, c := range items {
+		if c.Label == "[p](b.md#Part)" {
+			item = c
+		}
+	}
+	if item.Data == nil {
+		t.Fatal("No completion for [p](b.md#Part)")
+	}
+
+	t.Run("1 Items carry data instead of excerpt", func(t *testing.T) {
+		for _, c := range items {
+			if c.Documentation != nil {
+				t.Errorf("Documentation of %s >>> nil got %v", c.Label, c.Documentation)
+			}
+		}
+		want := CompletionData{URI: testNoteURI(s, "b.md"), SubTarget: "#Part"}
+		if got, ok := item.Data.(*CompletionData); !ok || *got != want {
+			t.Errorf("Data >>> %v got %v", want, item.Data)
+		}
+	})
+	t.Run("2 Resolve fills heading path and excerpt", func(t *testing.T) {
+		got := resolve(t, item)
+		if got.Detail != "b > B > Part" {
+			t.Errorf("Detail >>> [b > B > Part] got [%s]", got.Detail)
+		}
+		doc, ok := got.Documentation.(lsp.MarkupContent)
+		if !ok || !strings.HasPrefix(doc.Value, "1 references\n") || !strings.Contains(doc.Value, "text under part") {
+			t.Errorf("Documentation >>> references and excerpt got %v", got.Documentation)
+		}
+	})
+	t.Run("3 Resolve of file shows path", func(t *testing.T) {
+		got := resolve(t, lsp.CompletionItem{Label: "b", Data: s.getCompletionData(a, "")})
+		if got.Detail != "a.md" {
+			t.Errorf("Detail >>> [a.md] got [%s]", got.Detail)
+		}
+		if got.Documentation == nil {
+			t.Errorf("Documentation should be filled")
+		}
+	})
+	t.Run("4 Unknown note is left as is", func(t *testing.T) {
+		item := lsp.CompletionItem{Label: "x", Data: &CompletionData{URI: testNoteURI(s, "missing.md")}}
+		if got := resolve(t, item); got.Detail != "" || got.Documentation != nil {
+			t.Errorf("Item >>> unchanged got %v", got)
+		}
+	})
+}
@@ -10,13 +10,20 @@ import (
 func (s *Store) GetCompletions(params lsp.CompletionParams) ([]lsp.CompletionItem, error) {
 	completions := []lsp.CompletionItem{}
 
-	id := s.GetIdFromURI(params.TextDocument.URI)
-	doc, found := s.GetDoc(id)
+	var doc DocumentData
+	id, found := s.FindIdFromURI(params.TextDocument.URI)
+	if found {
+		doc, found = s.GetDoc(id)
+	}
 	if !found {
 		slog.Error("not found" + string(params.TextDocument.URI))
 		return completions, fmt.Errorf("Not found")
 	}
 
+	if fm, ok := ParseFrontMatter(string(doc.Content)); ok && params.Position.Line > fm.Range.Start.Line && params.Position.Line < fm.Range.End.Line {
+		return s.GetFrontMatterCompletions(params.TextDocument.URI, fm, doc.Content, params.Position), nil
+	}
+
 	line := doc.Content.GetLine(params.Position.Line)
 
 	// this case where space is not considrered by below code
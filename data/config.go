@@ -15,10 +15,24 @@ type Config struct {
 	RootMarkers              []string
 	IncludeMdExtensionMdLink bool `toml:"include_md_extension_md_link"`
 	MdLinkWebMode            bool `toml:"md_link_web_mode"`
+	RenameNestedTags         bool `toml:"rename_nested_tags"`
 	RootPath                 string
 	DateLayout               string
 	MonthDateLayout          string
 	MonthDateSubtargetLayout string
+	FrontMatter              map[string]FrontMatterKeySchema `toml:"front_matter"`
+}
+
+// optional checks of a front matter key, declared as
+//
+//	[front_matter.status]
+//	type = "string"
+//	values = ["draft", "done"]
+type FrontMatterKeySchema struct {
+	// string, number, bool, date or list
+	Type     string   `toml:"type"`
+	Values   []string `toml:"values"`
+	Required bool     `toml:"required"`
 }
 
 func NewConfig() Config {
@@ -30,6 +44,7 @@ func NewConfig() Config {
 		MonthDateLayout:          "2006-01-January",
 		MonthDateSubtargetLayout: "02 Monday",
 		MdLinkWebMode:            false,
+		RenameNestedTags:         true,
 	}
 }
 
@@ -0,0 +1,80 @@
+package data
+
+import (
+	"slices"
+	"sylmark/lsp"
+)
+
+// "" is the file itself, every link to the file depends on it
+func (s *Store) markDefChanged(id Id, subTarget SubTarget) {
+	subTargets, found := s.changedDefs[id]
+	if !found {
+		subTargets = map[SubTarget]bool{}
+		s.changedDefs[id] = subTargets
+	}
+	subTargets[subTarget] = true
+}
+
+func (s *Store) getDefSubTargets(id Id) map[SubTarget]bool {
+	subTargets := map[SubTarget]bool{}
+	for subTarget := range s.LinkStore[id].Def {
+		subTargets[subTarget] = true
+	}
+	return subTargets
+}
+
+// marks subTargets of id added or removed since before, defs only moving around change no diagnostics
+func (s *Store) markDefsChangedSince(id Id, before map[SubTarget]bool) {
+	after := s.getDefSubTargets(id)
+	for subTarget := range before {
+		if !after[subTarget] {
+			s.markDefChanged(id, subTarget)
+		}
+	}
+	for subTarget := range after {
+		if !before[subTarget] {
+			s.markDefChanged(id, subTarget)
+		}
+	}
+}
+
+// unresolved links to target are kept on it's shadow ids
+func (s *Store) markTargetChanged(target Target) {
+	for _, id := range s.TargetStore[target] {
+		s.markDefChanged(id, "")
+	}
+}
+
+// open documents linking to definitions changed since last call
+func (s *Store) TakeDependentDocs() (uris []lsp.DocumentURI) {
+	changed := s.changedDefs
+	s.changedDefs = map[Id]map[SubTarget]bool{}
+
+	affected := map[Id]bool{}
+	for id, subTargets := range changed {
+		link, found := s.LinkStore[id]
+		if !found {
+			continue
+		}
+		for subTarget, refs := range link.Refs {
+			// inline links keep encoded subTargets
+			decoded := SubTarget(s.DecodeForInlineLinkdownLinkPath(string(subTarget)))
+			if !subTargets[""] && !subTargets[subTarget] && !subTargets[decoded] {
+				continue
+			}
+			for _, ref := range refs {
+				if s.openDocs[ref.Id] {
+					affected[ref.Id] = true
+				}
+			}
+		}
+	}
+
+	for id := range affected {
+		if uri, ok := s.GetUri(id); ok {
+			uris = append(uris, uri)
+		}
+	}
+	slices.Sort(uris)
+	return uris
+}
@@ -0,0 +1,60 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sylmark/lsp"
+	"testing"
+)
+
+func TestTakeDependentDocs(t *testing.T) {
+	s, parse := newTestVault(t)
+	b := addTestNote(t, s, parse, "b.md", "# B\n\n## Part\ntext\n")
+	a := addTestNote(t, s, parse, "a.md", "see [part](b.md#Part)\n")
+	addTestNote(t, s, parse, "c.md", "see [part](b.md#Part)\n")
+	aUri := testNoteURI(s, "a.md")
+	s.OpenDoc(a, "see [part](b.md#Part)\n", parse)
+	s.TakeDependentDocs()
+
+	editB := func(text string) {
+		s.SyncChangedDocument(b, lsp.TextDocumentContentChangeEvent{Text: text}, parse)
+	}
+	t.Run("1 Body edit changes no defs", func(t *testing.T) {
+		editB("# B\nmore\n\n## Part\ntext\n")
+		if uris := s.TakeDependentDocs(); len(uris) != 0 {
+			t.Errorf("Dependent docs >>> [] got %v", uris)
+		}
+	})
+	t.Run("2 Unchanged note loaded again from disk", func(t *testing.T) {
+		path := filepath.Join(s.Config.RootPath, "b.md")
+		if err := os.WriteFile(path, []byte("# B\nmore\n\n## Part\ntext\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		s.ReloadChangedDocs([]lsp.DocumentURI{testNoteURI(s, "b.md")}, parse)
+		if uris := s.TakeDependentDocs(); len(uris) != 0 {
+			t.Errorf("Dependent docs >>> [] got %v", uris)
+		}
+	})
+	t.Run("3 Removed heading refreshes open docs linking to it", func(t *testing.T) {
+		editB("# B\nmore\n\ntext\n")
+		if uris := s.TakeDependentDocs(); !slices.Equal(uris, []lsp.DocumentURI{aUri}) {
+			t.Errorf("Dependent docs >>> [%s] got %v", aUri, uris)
+		}
+	})
+	t.Run("4 Heading added back", func(t *testing.T) {
+		editB("# B\nmore\n\n## Part\ntext\n")
+		if uris := s.TakeDependentDocs(); !slices.Equal(uris, []lsp.DocumentURI{aUri}) {
+			t.Errorf("Dependent docs >>> [%s] got %v", aUri, uris)
+		}
+		if uris := s.TakeDependentDocs(); len(uris) != 0 {
+			t.Errorf("Dependent docs taken twice >>> [] got %v", uris)
+		}
+	})
+	t.Run("5 Other heading changed", func(t *testing.T) {
+		editB("# Beta\nmore\n\n## Part\ntext\n")
+		if uris := s.TakeDependentDocs(); len(uris) != 0 {
+			t.Errorf("Dependent docs >>> [] got %v", uris)
+		}
+	})
+}
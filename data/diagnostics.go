@@ -1,114 +1,136 @@
 package data
 
 import (
+	"context"
 	"fmt"
 	"sylmark/lsp"
+	"time"
 
 	tree_sitter "github.com/tree-sitter/go-tree-sitter"
 )
 
-func (store *Store) GetDiagnostics(uri lsp.DocumentURI, parse lsp.ParseFunction) (items []lsp.Diagnostic) {
-	s := *store
+// link of a document which can be checked without it's trees
+type diagnosticLink struct {
+	Kind      string
+	Range     lsp.Range
+	Target    Target
+	SubTarget SubTarget
+	Dest      string
+}
+
+func getDiagnosticLinks(content string, trees *lsp.Trees) (links []diagnosticLink) {
+	lsp.TraverseNodeWith(trees.GetInlineTree().RootNode(), func(n *tree_sitter.Node) {
+		switch n.Kind() {
+		case "wiki_link":
+			target, subTarget, _, ok := GetWikilinkTargets(n, content)
+			if ok && !isEmbedNode(n, content) {
+				links = append(links, diagnosticLink{
+					Kind:      n.Kind(),
+					Range:     lsp.GetRange(n),
+					Target:    target,
+					SubTarget: subTarget,
+				})
+			}
+		case "inline_link", "image":
+			destNode := getLinkDestinationNode(n)
+			if destNode != nil {
+				links = append(links, diagnosticLink{
+					Kind:  n.Kind(),
+					Range: lsp.GetRange(n),
+					Dest:  lsp.GetNodeContent(*destNode, content),
+				})
+			}
+		}
+	})
+	// embeds resolve like wikilinks
+	for _, e := range getEmbeds(content, trees, allLines) {
+		links = append(links, diagnosticLink{
+			Kind:      "wiki_link",
+			Range:     e.rng,
+			Target:    e.target,
+			SubTarget: e.subTarget,
+		})
+	}
+	return links
+}
 
-	id := s.GetIdFromURI(uri)
+func (store *Store) GetDiagnostics(ctx context.Context, uri lsp.DocumentURI, parse lsp.ParseFunction) (items []lsp.Diagnostic) {
+	s := store
+
+	id, found := s.FindIdFromURI(uri)
+	if !found {
+		return
+	}
 	doc, ok := s.GetDocMustTree(id, parse)
 	if !ok {
 		return
 	}
 
+	links := getDiagnosticLinks(string(doc.Content), doc.Trees)
+	items = s.getLinkDiagnostics(ctx, id, links)
+	items = append(items, s.getFrontMatterDiagnostics(string(doc.Content))...)
+	return append(items, s.getTaskDiagnostics(id, time.Now())...)
+}
+
+func (s *Store) getLinkDiagnostics(ctx context.Context, id Id, links []diagnosticLink) (items []lsp.Diagnostic) {
 	items = []lsp.Diagnostic{}
+	uri, _ := s.GetUri(id)
 
-	content := string(doc.Content)
-	lsp.TraverseNodeWith(doc.Trees.GetMainTree().RootNode(), func(n *tree_sitter.Node) {
-		switch n.Kind() {
-		case "atx_heading":
+	for _, link := range links {
+		rng := link.Range
+		switch link.Kind {
+		case "wiki_link":
 			{
-				subTarget, ok := GetSubTarget(n, content)
-				if ok {
-					refs, found := s.LinkStore.GetRefs(id, subTarget)
-					subrefs, subfound := doc.Headings.GetRefs(string(subTarget))
-					if found || subfound {
-						if subfound && len(subrefs) > 0 {
-							rng := lsp.GetRange(n)
-							msg := fmt.Sprintf("%d+%d=%d ", len(refs), len(subrefs), len(refs)+len(subrefs))
-							if len(refs) == 0 {
-								msg = fmt.Sprintf("+%d", len(subrefs))
-							}
-							items = append(items, lsp.Diagnostic{
-								Range:    &rng,
-								Severity: lsp.DiagnosticSeverityInformation,
-								Message:  msg,
-							})
-						} else if len(refs) > 0 {
+				isSubheading := len(link.Target) == 0
+				if isSubheading {
+					_, found := s.LinkStore.GetDef(id, link.SubTarget)
+					if !found {
+						items = append(items, lsp.Diagnostic{
+							Range:    &rng,
+							Severity: lsp.DiagnosticSeverityInformation,
+							Message:  "Heading Unresolved",
+						})
+					}
+				} else {
+					_, found := s.GetDefsFromTarget(ctx, link.Target, link.SubTarget)
+					refs, rfound := s.GetRefsFromTarget(ctx, link.Target, link.SubTarget)
+					msg := "Unresolved"
+					if len(link.SubTarget) > 0 {
+						if _, fileFound := s.GetDefsFromTarget(ctx, link.Target, ""); fileFound {
+							msg = "Heading Unresolved"
+						}
+					}
+					if rfound {
+						if len(refs) > 1 {
 
-							rng := lsp.GetRange(n)
-							items = append(items, lsp.Diagnostic{
-								Range:    &rng,
-								Severity: lsp.DiagnosticSeverityInformation,
-								Message:  fmt.Sprintf("%d", len(refs)),
-							})
+							msg = fmt.Sprintf("%s | %d ", msg, len(refs))
+						} else {
+							msg = fmt.Sprintf("%s ", msg)
 						}
 					}
+					if !found {
+						items = append(items, lsp.Diagnostic{
+							Range:    &rng,
+							Severity: lsp.DiagnosticSeverityInformation,
+							Message:  msg,
+						})
+					}
 				}
 			}
-		}
-	})
-	lsp.TraverseNodeWith(doc.Trees.GetInlineTree().RootNode(), func(n *tree_sitter.Node) {
-		switch n.Kind() {
-		case "wiki_link":
+		case "inline_link", "image":
 			{
-				target, subTarget, _, ok := GetWikilinkTargets(n, content)
-				if ok {
-					isSubheading := len(target) == 0
-					if isSubheading {
-						starget := string(subTarget)
-						_, found := doc.Headings.GetDef(starget)
-						if !found {
-							rng := lsp.GetRange(n)
-							items = append(items, lsp.Diagnostic{
-								Range:    &rng,
-								Severity: lsp.DiagnosticSeverityInformation,
-								Tags:     []lsp.DiagnosticTag{lsp.DiagnosticTagUnnecessary},
-								Message:  "Heading Unresolved",
-							})
-						}
-					} else {
-						_, found := s.GetDefsFromTarget(target, subTarget)
-						refs, rfound := s.GetRefsFromTarget(target, subTarget)
-						// utils.Sprintf("%d defs, %v found | target=%s subTarget%s %drefs", len(defs), found, target, subTarget,len(refs))
-						msg := "Unresolved"
-						if rfound {
-							if len(refs) > 1 {
-
-								msg = fmt.Sprintf("%s | %d ", msg, len(refs))
-							} else {
-								msg = fmt.Sprintf("%s ", msg)
-							}
-						}
-						if !found {
-							rng := lsp.GetRange(n)
-							items = append(items, lsp.Diagnostic{
-								Range:    &rng,
-								Severity: lsp.DiagnosticSeverityInformation,
-								Tags:     []lsp.DiagnosticTag{lsp.DiagnosticTagUnnecessary},
-								Message:  msg,
-							})
-						}
-					}
+				msg, ok := s.checkInlineDestination(id, uri, link)
+				if !ok {
+					items = append(items, lsp.Diagnostic{
+						Range:    &rng,
+						Severity: lsp.DiagnosticSeverityWarning,
+						Message:  msg,
+					})
 				}
 			}
 		}
-	})
-
-	refs, rfound := s.LinkStore.GetRefs(id, "")
-	if rfound && len(refs) > 0 {
-		msg := fmt.Sprintf("F%d", len(refs))
-		items = append(items, lsp.Diagnostic{
-			Severity: lsp.DiagnosticSeverityInformation,
-			Message:  msg,
-			Range:    &lsp.Range{},
-		})
 	}
+
 	// file warning for duplicate names
 	// target, ok := GetTarget(uri)
 	// if ok {
@@ -126,3 +148,29 @@ func (store *Store) GetDiagnostics(uri lsp.DocumentURI, parse lsp.ParseFunction)
 
 	return items
 }
+
+// message for dangling inline link or link to missing heading
+func (s *Store) checkInlineDestination(id Id, uri lsp.DocumentURI, link diagnosticLink) (msg string, ok bool) {
+	if len(link.Dest) == 0 || link.Dest[0] == '#' || isExternalLink(link.Dest) {
+		return "", true
+	}
+	if _, ok := s.resolveInlineDestination(id, uri, link.Dest, link.Kind == "image"); !ok {
+		return "Dangling link", false
+	}
+	dest := s.Config.ProcessInlineTargetPath(link.Dest)
+	if _, isAttachment := s.getInlineAttachmentPath(uri, dest); isAttachment {
+		return "", true
+	}
+	_, targetId, subTarget, found := s.GetInlineTargetAndSubTarget(dest, id)
+	if !found || len(subTarget) == 0 {
+		return "", true
+	}
+	if _, ok := s.LinkStore.GetDef(targetId, subTarget); ok {
+		return "", true
+	}
+	decoded := SubTarget(s.DecodeForInlineLinkdownLinkPath(string(subTarget)))
+	if _, ok := s.LinkStore.GetDef(targetId, decoded); ok {
+		return "", true
+	}
+	return "Heading Unresolved", false
+}
@@ -0,0 +1,353 @@
+package data
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"sylmark/lsp"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// what a node of document adds to the stores, comparable so that versions of a document can be diffed
+type docEntry struct {
+	Kind      string
+	Range     lsp.Range
+	Target    Target
+	SubTarget SubTarget
+	LinkId    Id
+	Tag       Tag
+	// destination of inline links, ids are resolved from it again when loaded from cache
+	Url string
+	// front matter key and value, title of the note, text of task, front matter tag as written
+	Key   string
+	Value string
+	// task state and due date
+	Checked bool
+	Due     string
+}
+
+// lines of a document from start to end, both included
+type lineSpan struct {
+	start int
+	end   int
+}
+
+var allLines = lineSpan{start: 0, end: math.MaxInt}
+
+// last line having any of rng, nodes of whole lines end at start of next one
+func lastLine(rng lsp.Range) int {
+	if rng.End.Character == 0 && rng.End.Line > rng.Start.Line {
+		return rng.End.Line - 1
+	}
+	return rng.End.Line
+}
+
+func (l lineSpan) overlaps(rng lsp.Range) bool {
+	return rng.Start.Line <= l.end && lastLine(rng) >= l.start
+}
+
+func (l lineSpan) grow(start int, end int) lineSpan {
+	return lineSpan{start: min(l.start, start), end: max(l.end, end)}
+}
+
+// calls fn with lines of content within span and their numbers
+func forLinesWithin(content string, span lineSpan, fn func(i int, line string)) {
+	offset := Document(content).OffsetAt(lsp.Position{Line: span.start})
+	for i := span.start; i <= span.end; i++ {
+		end := strings.IndexByte(content[offset:], '\n')
+		if end == -1 {
+			fn(i, content[offset:])
+			return
+		}
+		fn(i, content[offset:offset+end])
+		offset += end + 1
+	}
+}
+
+func (s *Store) getDocEntries(id Id, content string, trees *lsp.Trees) []docEntry {
+	return s.getDocEntriesWithin(id, content, trees, allLines, "")
+}
+
+// entries overlapping span, heading is the one above span which tasks are under
+func (s *Store) getDocEntriesWithin(id Id, content string, trees *lsp.Trees, span lineSpan, heading SubTarget) (entries []docEntry) {
+	fm, hasFrontMatter := ParseFrontMatter(content)
+	if hasFrontMatter && span.overlaps(fm.Range) {
+		entries = append(entries, getFrontMatterEntries(fm)...)
+	}
+	startRow, endRow := uint(span.start), uint(span.end)
+
+	lsp.TraverseNodeWithin(trees.GetMainTree().RootNode(), startRow, endRow, func(n *tree_sitter.Node) {
+		switch n.Kind() {
+		case "atx_heading":
+			{
+				subTarget, ok := GetSubTarget(n, content)
+				if ok {
+					entries = append(entries, docEntry{
+						Kind:      n.Kind(),
+						Range:     lsp.GetRange(n),
+						SubTarget: subTarget,
+					})
+				}
+			}
+		}
+	})
+
+	for _, b := range getBlockIds(content, trees, span) {
+		entries = append(entries, docEntry{
+			Kind:      "block_id",
+			Range:     b.rng,
+			SubTarget: b.subTarget,
+		})
+	}
+
+	for _, e := range getEmbeds(content, trees, span) {
+		if len(e.target) > 0 {
+			entries = append(entries, docEntry{
+				Kind:      "embed",
+				Range:     e.rng,
+				Target:    e.target,
+				SubTarget: e.subTarget,
+			})
+		}
+	}
+
+	for _, t := range s.getTasks(content, trees, span, heading) {
+		entries = append(entries, docEntry{
+			Kind:      "task",
+			Range:     t.Range,
+			SubTarget: t.Heading,
+			Value:     t.Text,
+			Checked:   t.Checked,
+			Due:       t.Due,
+		})
+	}
+
+	lsp.TraverseNodeWithin(trees.GetInlineTree().RootNode(), startRow, endRow, func(n *tree_sitter.Node) {
+		switch n.Kind() {
+		case "wiki_link":
+			{
+				target, subTarget, _, ok := GetWikilinkTargets(n, content)
+				isSubheading := len(target) == 0
+				if ok && !isSubheading && !isEmbedNode(n, content) {
+					entries = append(entries, docEntry{
+						Kind:      n.Kind(),
+						Range:     lsp.GetRange(n),
+						Target:    target,
+						SubTarget: subTarget,
+					})
+				}
+			}
+		case "tag":
+			{
+				// already taken from front matter fields
+				if hasFrontMatter && fm.ContainsLine(int(n.StartPosition().Row)) {
+					return
+				}
+				entries = append(entries, docEntry{
+					Kind:  n.Kind(),
+					Range: lsp.GetRange(n),
+					Tag:   GetTag(n, content),
+				})
+			}
+		case "inline_link":
+			fullUrl, err := s.Config.GetInlineLinkTarget(n, content)
+			if err != nil {
+				return
+			}
+			_, linkId, subTarget, found := s.addInlineTargetAndSubTarget(fullUrl, id)
+			if found {
+				entries = append(entries, docEntry{
+					Kind:      n.Kind(),
+					Range:     lsp.GetRange(n),
+					SubTarget: subTarget,
+					LinkId:    linkId,
+					Url:       fullUrl,
+				})
+			}
+		}
+	})
+	// nodes may reach lines outside span while entries of them don't
+	return slices.DeleteFunc(entries, func(e docEntry) bool {
+		return !span.overlaps(e.Range)
+	})
+}
+
+func (e docEntry) task() Task {
+	return Task{
+		Range:   e.Range,
+		Checked: e.Checked,
+		Text:    e.Value,
+		Heading: e.SubTarget,
+		Due:     e.Due,
+	}
+}
+
+func (s *Store) loadEntry(id Id, uri lsp.DocumentURI, e docEntry) {
+	loc := IdLocation{Id: id, Range: e.Range}
+	switch e.Kind {
+	case "atx_heading", "block_id":
+		s.LinkStore.AddDef(id, e.SubTarget, e.Range)
+	case "wiki_link", "embed":
+		for _, defId := range s.getIds(e.Target) {
+			s.LinkStore.AddRef(defId, e.SubTarget, loc)
+		}
+	case "tag":
+		tagLoc := lsp.Location{URI: uri, Range: e.Range}
+		s.addTagLocation(e.Tag, tagLoc)
+		if len(e.Value) > 0 && !strings.HasPrefix(e.Value, "#") {
+			s.tagsWithoutHash[tagLoc] = true
+		}
+	case "inline_link":
+		s.LinkStore.AddRef(e.LinkId, e.SubTarget, loc)
+	case "alias":
+		s.addAlias(id, e.Target)
+	case "title":
+		s.titles[id] = e.Value
+	case "front_matter_key":
+		addLocation(s.FrontMatterKeys, e.Key, lsp.Location{URI: uri, Range: e.Range})
+	case "front_matter_value":
+		s.addFrontMatterValueLocation(e.Key, e.Value, lsp.Location{URI: uri, Range: e.Range})
+	case "task":
+		s.addTask(id, e.task())
+	}
+}
+
+func (s *Store) unloadEntry(id Id, uri lsp.DocumentURI, e docEntry) {
+	loc := IdLocation{Id: id, Range: e.Range}
+	switch e.Kind {
+	case "atx_heading", "block_id":
+		s.LinkStore.RemoveDef(id, e.SubTarget, e.Range)
+	case "wiki_link", "embed":
+		for _, defId := range s.getIds(e.Target) {
+			s.LinkStore.RemoveRef(defId, e.SubTarget, loc)
+		}
+	case "tag":
+		tagLoc := lsp.Location{URI: uri, Range: e.Range}
+		s.removeTagLocation(e.Tag, tagLoc)
+		delete(s.tagsWithoutHash, tagLoc)
+	case "inline_link":
+		s.LinkStore.RemoveRef(e.LinkId, e.SubTarget, loc)
+	case "alias":
+		s.removeAlias(id, e.Target)
+	case "title":
+		if s.titles[id] == e.Value {
+			delete(s.titles, id)
+		}
+	case "front_matter_key":
+		removeLocation(s.FrontMatterKeys, e.Key, lsp.Location{URI: uri, Range: e.Range})
+	case "front_matter_value":
+		s.removeFrontMatterValueLocation(e.Key, e.Value, lsp.Location{URI: uri, Range: e.Range})
+	case "task":
+		s.removeTask(id, e.task())
+	}
+}
+
+// unloads entries gone from oldEntries and loads the new ones, untouched nodes stay as they are
+func (s *Store) syncEntries(id Id, oldEntries []docEntry, newEntries []docEntry) {
+	uri, _ := s.GetUri(id)
+	defs := s.getDefSubTargets(id)
+	defer s.markDefsChangedSince(id, defs)
+	counts := map[docEntry]int{}
+	for _, e := range newEntries {
+		counts[e]++
+	}
+	// heading and block defs are removed by subTarget, same ones left have to be loaded again
+	removedHeadings := map[SubTarget]bool{}
+	for _, e := range oldEntries {
+		if counts[e] > 0 {
+			counts[e]--
+			continue
+		}
+		s.unloadEntry(id, uri, e)
+		if e.Kind == "atx_heading" || e.Kind == "block_id" {
+			removedHeadings[e.SubTarget] = true
+		}
+	}
+	for _, e := range newEntries {
+		if counts[e] > 0 {
+			counts[e]--
+			s.loadEntry(id, uri, e)
+		} else if (e.Kind == "atx_heading" || e.Kind == "block_id") && removedHeadings[e.SubTarget] {
+			s.loadEntry(id, uri, e)
+		}
+	}
+}
+
+func isHeadingEntry(e docEntry) bool {
+	return e.Kind == "atx_heading"
+}
+
+// heading of entries which is lowest in the document
+func getHeadingAbove(entries []docEntry) (heading SubTarget) {
+	line := -1
+	for _, e := range entries {
+		if isHeadingEntry(e) && e.Range.Start.Line > line {
+			line, heading = e.Range.Start.Line, e.SubTarget
+		}
+	}
+	return heading
+}
+
+// entries ending before span, overlapping it and starting after it
+func splitEntries(entries []docEntry, span lineSpan) (above []docEntry, within []docEntry, below []docEntry) {
+	for _, e := range entries {
+		switch {
+		case lastLine(e.Range) < span.start:
+			above = append(above, e)
+		case e.Range.Start.Line > span.end:
+			below = append(below, e)
+		default:
+			within = append(within, e)
+		}
+	}
+	return above, within, below
+}
+
+// entries below an edit keep what they add to stores, only their ranges move
+func (s *Store) shiftEntries(id Id, entries []docEntry, delta int) []docEntry {
+	if delta == 0 {
+		return entries
+	}
+	uri, _ := s.GetUri(id)
+	shifted := make([]docEntry, len(entries))
+	for i, e := range entries {
+		moved := e
+		moved.Range.Start.Line += delta
+		moved.Range.End.Line += delta
+		s.moveEntry(id, uri, e, moved)
+		shifted[i] = moved
+	}
+	return shifted
+}
+
+func (s *Store) moveEntry(id Id, uri lsp.DocumentURI, e docEntry, moved docEntry) {
+	loc := IdLocation{Id: id, Range: e.Range}
+	movedLoc := IdLocation{Id: id, Range: moved.Range}
+	docLoc := lsp.Location{URI: uri, Range: e.Range}
+	movedDocLoc := lsp.Location{URI: uri, Range: moved.Range}
+	switch e.Kind {
+	case "atx_heading", "block_id":
+		s.LinkStore.moveDef(id, e.SubTarget, e.Range, moved.Range)
+	case "wiki_link", "embed":
+		for _, defId := range s.getIds(e.Target) {
+			s.LinkStore.moveRef(defId, e.SubTarget, loc, movedLoc)
+		}
+	case "inline_link":
+		s.LinkStore.moveRef(e.LinkId, e.SubTarget, loc, movedLoc)
+	case "tag":
+		moveLocation(s.Tags, e.Tag, docLoc, movedDocLoc)
+		if s.tagsWithoutHash[docLoc] {
+			delete(s.tagsWithoutHash, docLoc)
+			s.tagsWithoutHash[movedDocLoc] = true
+		}
+	case "front_matter_key":
+		moveLocation(s.FrontMatterKeys, e.Key, docLoc, movedDocLoc)
+	case "front_matter_value":
+		moveLocation(s.FrontMatterValues[e.Key], e.Value, docLoc, movedDocLoc)
+	case "task":
+		if i := slices.Index(s.Tasks[id], e.task()); i != -1 {
+			s.Tasks[id][i] = moved.task()
+		}
+	}
+}
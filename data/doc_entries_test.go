@@ -0,0 +1,134 @@
+package data
+
+import (
+	"maps"
+	"slices"
+	"sylmark/lsp"
+	"testing"
+)
+
+func countEntries(entries []docEntry) map[docEntry]int {
+	counts := map[docEntry]int{}
+	for _, e := range entries {
+		counts[e]++
+	}
+	return counts
+}
+
+func TestSyncEntries(t *testing.T) {
+	s := newAliasTestStore()
+	id := s.GetIdFromURI("file:///vault/a.md")
+	uri, _ := s.GetUri(id)
+
+	t.Run("1 Same entries are counted", func(t *testing.T) {
+		alias := docEntry{Kind: "alias", Range: lineRange(1), Target: "My Alias"}
+		work := docEntry{Kind: "tag", Range: lineRange(2), Tag: "#work"}
+		home := docEntry{Kind: "tag", Range: lineRange(3), Tag: "#home"}
+		s.loadEntries(id, []docEntry{alias, alias, work})
+		s.syncEntries(id, []docEntry{alias, alias, work}, []docEntry{alias, work, home})
+
+		if aliases := s.aliases[id]; len(aliases) != 1 {
+			t.Errorf("Aliases >>> [My Alias] got %v", aliases)
+		}
+		if ids := s.TargetStore["My Alias"]; !slices.Equal(ids, []Id{id}) {
+			t.Errorf("TargetStore >>> [%d] got %v", id, ids)
+		}
+		if locs := s.Tags["#work"]; len(locs) != 1 {
+			t.Errorf("#work >>> 1 location got %v", locs)
+		}
+		want := lsp.Location{URI: uri, Range: lineRange(3)}
+		if locs := s.Tags["#home"]; len(locs) != 1 || locs[0] != want {
+			t.Errorf("#home >>> [%v] got %v", want, locs)
+		}
+	})
+	t.Run("2 Heading left with removed subTarget is added again", func(t *testing.T) {
+		first := docEntry{Kind: "atx_heading", Range: lineRange(5), SubTarget: "#Part"}
+		second := docEntry{Kind: "atx_heading", Range: lineRange(9), SubTarget: "#Part"}
+		s.loadEntries(id, []docEntry{first, second})
+		s.syncEntries(id, []docEntry{first, second}, []docEntry{second})
+
+		if rng, found := s.LinkStore.GetDef(id, "#Part"); !found || rng != second.Range {
+			t.Errorf("Def of #Part >>> %v got %v", second.Range, rng)
+		}
+	})
+	t.Run("3 Removed heading is gone", func(t *testing.T) {
+		part := docEntry{Kind: "atx_heading", Range: lineRange(9), SubTarget: "#Part"}
+		s.syncEntries(id, []docEntry{part}, nil)
+		if _, found := s.LinkStore.GetDef(id, "#Part"); found {
+			t.Errorf("Def of #Part should be removed")
+		}
+	})
+}
+
+func TestSyncEditedDocument(t *testing.T) {
+	s, parse := newTestVault(t)
+	addTestNote(t, s, parse, "b.md", "# B\n")
+	b := s.GetIdFromURI(testNoteURI(s, "b.md"))
+	a := addTestNote(t, s, parse, "a.md", "# A\n- [ ] one\n\n[b](b.md)\n\n## Part\n- [ ] two ^blk\n")
+
+	edit := func(startLine, startChar, endLine, endChar int, text string) {
+		rng := lsp.Range{
+			Start: lsp.Position{Line: startLine, Character: startChar},
+			End:   lsp.Position{Line: endLine, Character: endChar},
+		}
+		s.SyncChangedDocument(a, lsp.TextDocumentContentChangeEvent{Range: &rng, Text: text}, parse)
+	}
+	// entries kept while editing have to be what a fresh parse gives
+	checkEntries := func(t *testing.T) {
+		doc, _ := s.GetDoc(a)
+		want := countEntries(s.getDocEntries(a, string(doc.Content), doc.Trees))
+		if got := countEntries(s.docEntries[a]); !maps.Equal(want, got) {
+			t.Errorf("Entries >>> %v got %v", want, got)
+		}
+	}
+
+	t.Run("1 Lines below are shifted without reloading", func(t *testing.T) {
+		clear(s.changedDefs)
+		edit(2, 0, 2, 0, "new line\n")
+		checkEntries(t)
+		if len(s.changedDefs) != 0 {
+			t.Errorf("Changed defs >>> [] got %v", s.changedDefs)
+		}
+		if rng, _ := s.LinkStore.GetDef(a, "#Part"); rng.Start.Line != 6 {
+			t.Errorf("Def of #Part >>> line 6 got %v", rng)
+		}
+		if rng, _ := s.LinkStore.GetDef(a, "#^blk"); rng.Start.Line != 7 {
+			t.Errorf("Def of #^blk >>> line 7 got %v", rng)
+		}
+		refs, _ := s.LinkStore.GetRefs(b, "")
+		if len(refs) != 1 || refs[0].Range.Start.Line != 4 {
+			t.Errorf("Refs of b >>> line 4 got %v", refs)
+		}
+		if tasks := s.Tasks[a]; len(tasks) != 2 || tasks[1].Range.Start.Line != 7 {
+			t.Errorf("Tasks >>> two on line 7 got %v", tasks)
+		}
+	})
+	t.Run("2 Removed lines", func(t *testing.T) {
+		edit(2, 0, 3, 0, "")
+		checkEntries(t)
+		refs, _ := s.LinkStore.GetRefs(b, "")
+		if len(refs) != 1 || refs[0].Range.Start.Line != 3 {
+			t.Errorf("Refs of b >>> line 3 got %v", refs)
+		}
+	})
+	t.Run("3 Tasks follow renamed heading", func(t *testing.T) {
+		edit(0, 3, 0, 3, "lpha")
+		checkEntries(t)
+		headings := map[string]SubTarget{}
+		for _, task := range s.Tasks[a] {
+			headings[task.Text] = task.Heading
+		}
+		want := map[string]SubTarget{"one": "#Alpha", "two ^blk": "#Part"}
+		if !maps.Equal(want, headings) {
+			t.Errorf("Task headings >>> %v got %v", want, headings)
+		}
+	})
+	t.Run("4 Edit within line", func(t *testing.T) {
+		edit(3, 1, 3, 2, "see b")
+		checkEntries(t)
+		refs, _ := s.LinkStore.GetRefs(b, "")
+		if len(refs) != 1 || refs[0].Range.End.Character != 13 {
+			t.Errorf("Refs of b >>> ending at 13 got %v", refs)
+		}
+	})
+}
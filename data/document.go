@@ -9,6 +9,8 @@ import (
 	"path/filepath"
 	"strings"
 	"sylmark/lsp"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
 )
 
 type Document string
@@ -40,6 +42,48 @@ func (doc *Document) GetLine(lineNumber int) string {
 	return line
 }
 
+// byte offset of position, character is a byte column like tree-sitter's
+func (doc Document) OffsetAt(pos lsp.Position) int {
+	offset := 0
+	for range pos.Line {
+		i := strings.IndexByte(string(doc[offset:]), '\n')
+		if i == -1 {
+			return len(doc)
+		}
+		offset += i + 1
+	}
+	lineLength := strings.IndexByte(string(doc[offset:]), '\n')
+	if lineLength == -1 {
+		lineLength = len(doc) - offset
+	}
+	return offset + min(max(pos.Character, 0), lineLength)
+}
+
+// replaces rng with text, edit is meant for Tree.Edit of the old trees
+func (doc Document) ApplyChange(rng lsp.Range, text string) (Document, tree_sitter.InputEdit) {
+	startByte := doc.OffsetAt(rng.Start)
+	oldEndByte := max(doc.OffsetAt(rng.End), startByte)
+	updated := doc[:startByte] + Document(text) + doc[oldEndByte:]
+
+	startPoint := lsp.PointFromPosition(rng.Start)
+	newEndPoint := startPoint
+	if i := strings.LastIndexByte(text, '\n'); i == -1 {
+		newEndPoint.Column += uint(len(text))
+	} else {
+		newEndPoint.Row += uint(strings.Count(text, "\n"))
+		newEndPoint.Column = uint(len(text) - i - 1)
+	}
+
+	return updated, tree_sitter.InputEdit{
+		StartByte:      uint(startByte),
+		OldEndByte:     uint(oldEndByte),
+		NewEndByte:     uint(startByte + len(text)),
+		StartPosition:  startPoint,
+		OldEndPosition: lsp.PointFromPosition(rng.End),
+		NewEndPosition: newEndPoint,
+	}
+}
+
 func DirPathFromURI(uri lsp.DocumentURI) (path string, er error) {
 	parsedUrl, err := url.Parse(string(uri))
 	if err != nil {
@@ -137,7 +181,9 @@ func (s *Store) GetExcerpt(id Id, rng lsp.Range) string {
 	if endLine >= len(lines) {
 		endLine = len(lines) - 1
 	}
-	// syltodo make it safe
+	if startLine < 0 || startLine > endLine {
+		return ""
+	}
 	exLines := lines[startLine:endLine]
 	return fmt.Sprintf("\n`Preview`\n\n%s\n`...`\n", string(bytes.Join(exLines, []byte("\n"))))
 }
@@ -0,0 +1,178 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sylmark/lsp"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// urls like https://, mailto: are already clickable targets
+func isExternalLink(dest string) bool {
+	return strings.Contains(dest, "://") || strings.HasPrefix(dest, "mailto:")
+}
+
+// uri with line fragment like file:///note.md#L12 for a heading def
+func getLineTargetURI(uri lsp.DocumentURI, rng lsp.Range, withLine bool) lsp.DocumentURI {
+	if !withLine {
+		return uri
+	}
+	return lsp.DocumentURI(fmt.Sprintf("%s#L%d", uri, rng.Start.Line+1))
+}
+
+// links for wiki_link, inline_link and image, target is filled lazily in ResolveDocumentLink
+func (s *Store) GetDocumentLinks(id Id, parse lsp.ParseFunction) []lsp.DocumentLink {
+	links := []lsp.DocumentLink{}
+	uri, ok := s.GetUri(id)
+	if !ok {
+		return links
+	}
+	docData, ok := s.GetDocMustTree(id, parse)
+	if !ok {
+		return links
+	}
+	content := string(docData.Content)
+	lsp.TraverseNodeWith(docData.Trees.GetInlineTree().RootNode(), func(n *tree_sitter.Node) {
+		switch n.Kind() {
+		case "wiki_link", "inline_link", "image":
+			destNode := getLinkDestinationNode(n)
+			if destNode == nil {
+				return
+			}
+			link := lsp.DocumentLink{
+				Range: lsp.GetRange(n),
+			}
+			dest := lsp.GetNodeContent(*destNode, content)
+			if isExternalLink(dest) {
+				target := lsp.DocumentURI(dest)
+				link.Target = &target
+			} else {
+				link.Data = &lsp.TextDocumentPositionParams{
+					TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+					Position:     lsp.GetRange(destNode).Start,
+				}
+			}
+			links = append(links, link)
+		}
+	})
+	return links
+}
+
+// fills target of link from GetDocumentLinks, target stays empty for unresolved links
+func (s *Store) ResolveDocumentLink(ctx context.Context, link lsp.DocumentLink, parse lsp.ParseFunction) lsp.DocumentLink {
+	if link.Data == nil || link.Target != nil {
+		return link
+	}
+	uri, _ := CleanUpURI(string(link.Data.TextDocument.URI))
+	id, found := s.FindIdFromURI(uri)
+	if !found {
+		return link
+	}
+	docData, ok := s.GetDocMustTree(id, parse)
+	if !ok {
+		return link
+	}
+	node, ok := s.getLinkNodeAt(docData, lsp.Range{Start: link.Data.Position, End: link.Data.Position})
+	if !ok {
+		return link
+	}
+
+	var target lsp.DocumentURI
+	switch node.Kind() {
+	case "wiki_link":
+		target, ok = s.resolveWikiLinkTarget(ctx, id, uri, node, docData, parse)
+	case "inline_link", "image":
+		target, ok = s.resolveInlineLinkTarget(id, uri, node, string(docData.Content))
+	}
+	if ok {
+		link.Target = &target
+	}
+	return link
+}
+
+func (s *Store) resolveWikiLinkTarget(ctx context.Context, id Id, uri lsp.DocumentURI, node *tree_sitter.Node, docData DocumentData, parse lsp.ParseFunction) (lsp.DocumentURI, bool) {
+	target, subTarget, _, _ := GetWikilinkTargets(node, string(docData.Content))
+	if len(target) == 0 {
+		// [[#Heading]] within file
+		headings := docData.Headings
+		if headings == nil {
+			headings = s.GetLoadedDataStore(id, parse)
+		}
+		rng, found := headings.GetDef(string(subTarget))
+		return getLineTargetURI(uri, rng, true), found
+	}
+
+	defs, found := s.GetDefsFromTarget(ctx, target, subTarget)
+	if found && len(subTarget) > 0 && len(defs) > 0 {
+		if targetUri, ok := s.GetUri(defs[0].Id); ok {
+			return getLineTargetURI(targetUri, defs[0].Range, true), true
+		}
+	}
+	defs, found = s.GetDefsFromTarget(ctx, target, "")
+	if found && len(defs) > 0 {
+		return s.GetUri(defs[0].Id)
+	}
+
+	// [[attachment.pdf]] or [[dir/attachment.pdf]]
+	suffix := string(filepath.Separator) + filepath.FromSlash(string(target))
+	i := slices.IndexFunc(s.OtherFiles, func(path string) bool {
+		return strings.HasSuffix(path, suffix)
+	})
+	if i == -1 {
+		return "", false
+	}
+	targetUri, err := UriFromPath(s.OtherFiles[i])
+	return targetUri, err == nil
+}
+
+func (s *Store) resolveInlineLinkTarget(id Id, uri lsp.DocumentURI, node *tree_sitter.Node, content string) (lsp.DocumentURI, bool) {
+	destNode := getLinkDestinationNode(node)
+	if destNode == nil {
+		return "", false
+	}
+	dest := lsp.GetNodeContent(*destNode, content)
+	return s.resolveInlineDestination(id, uri, dest, node.Kind() == "image")
+}
+
+// uri of attachment or note for link destination of inline_link or image
+func (s *Store) resolveInlineDestination(id Id, uri lsp.DocumentURI, dest string, isImage bool) (lsp.DocumentURI, bool) {
+	dest = s.Config.ProcessInlineTargetPath(dest)
+
+	// attachments like images and pdfs
+	if path, ok := s.getInlineAttachmentPath(uri, dest); ok {
+		targetUri, err := UriFromPath(path)
+		return targetUri, err == nil
+	}
+	if isImage {
+		return "", false
+	}
+
+	_, targetId, subTarget, found := s.GetInlineTargetAndSubTarget(dest, id)
+	if !found {
+		return "", false
+	}
+	if _, ok := s.LinkStore.GetDef(targetId, ""); !ok {
+		return "", false
+	}
+	targetUri, ok := s.GetUri(targetId)
+	if !ok {
+		return "", false
+	}
+	rng, ok := s.LinkStore.GetDef(targetId, subTarget)
+	return getLineTargetURI(targetUri, rng, ok && len(subTarget) > 0), true
+}
+
+// full path of non markdown file the link destination points to
+func (s *Store) getInlineAttachmentPath(uri lsp.DocumentURI, dest string) (string, bool) {
+	path, _, _ := strings.Cut(dest, "#")
+	// names of attachments are kept as they are, web mode dashes are only for notes
+	path = strings.ReplaceAll(path, "%20", " ")
+	if !filepath.IsAbs(path) {
+		path, _ = GetFullPathRelatedTo(uri, path)
+	}
+	return path, slices.Contains(s.OtherFiles, path)
+}
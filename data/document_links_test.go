@@ -0,0 +1,91 @@
+package data
+
+import (
+	"context"
+	"maps"
+	"path/filepath"
+	"sylmark/lsp"
+	"testing"
+)
+
+// resolved target by line of link, empty when it stays unresolved
+func getTestLinkTargets(s *Store, id Id, parse lsp.ParseFunction) map[int]string {
+	targets := map[int]string{}
+	for _, link := range s.GetDocumentLinks(id, parse) {
+		link = s.ResolveDocumentLink(context.Background(), link, parse)
+		target := ""
+		if link.Target != nil {
+			target = string(*link.Target)
+		}
+		targets[link.Range.Start.Line] = target
+	}
+	return targets
+}
+
+// adds attachment the way vault loading lists non markdown files
+func addTestAttachment(t *testing.T, s *Store, name string) string {
+	path := filepath.Join(s.Config.RootPath, name)
+	s.OtherFiles = append(s.OtherFiles, path)
+	uri, err := UriFromPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(uri)
+}
+
+func TestDocumentLinks(t *testing.T) {
+	s, parse := newTestVault(t)
+	addTestNote(t, s, parse, "b.md", "# B\n\n## Part\n")
+	img := addTestAttachment(t, s, "img.png")
+	photo := addTestAttachment(t, s, "my photo.png")
+	pdf := addTestAttachment(t, s, "docs/doc.pdf")
+	b := string(testNoteURI(s, "b.md"))
+
+	t.Run("1 Inline links, images and urls", func(t *testing.T) {
+		a := addTestNote(t, s, parse, "a.md", "[b](b.md)\n[p](b.md#Part)\n![i](img.png)\n[w](https://example.com/x)\n[m](missing.md)\n![s](my%20photo.png)\n")
+		want := map[int]string{
+			0: b,
+			1: b + "#L3",
+			2: img,
+			3: "https://example.com/x",
+			4: "",
+			5: photo,
+		}
+		if got := getTestLinkTargets(s, a, parse); !maps.Equal(got, want) {
+			t.Errorf("Targets >>> %v got %v", want, got)
+		}
+	})
+	t.Run("2 Urls are targets without resolve", func(t *testing.T) {
+		a := addTestNote(t, s, parse, "urls.md", "[w](https://example.com/x) [m](mailto:me@example.com)\n")
+		for _, link := range s.GetDocumentLinks(a, parse) {
+			if link.Target == nil || link.Data != nil {
+				t.Errorf("Link >>> target without data got %+v", link)
+			}
+		}
+	})
+	t.Run("3 Wiki links", func(t *testing.T) {
+		a := addTestNote(t, s, parse, "wiki.md", "[[b]]\n[[b#Part]]\n[[#Own]]\n[[doc.pdf]]\n[[missing]]\n# Own\n")
+		want := map[int]string{
+			0: b,
+			1: b + "#L3",
+			2: string(testNoteURI(s, "wiki.md")) + "#L6",
+			3: pdf,
+			4: "",
+		}
+		if got := getTestLinkTargets(s, a, parse); !maps.Equal(got, want) {
+			t.Errorf("Targets >>> %v got %v", want, got)
+		}
+	})
+}
+
+func TestDocumentLinksWebMode(t *testing.T) {
+	s, parse := newTestVault(t)
+	s.Config.MdLinkWebMode = true
+	photo := addTestAttachment(t, s, "my-photo.png")
+	a := addTestNote(t, s, parse, "a.md", "![p](my-photo.png)\n")
+
+	want := map[int]string{0: photo}
+	if got := getTestLinkTargets(s, a, parse); !maps.Equal(got, want) {
+		t.Errorf("Targets >>> %v got %v", want, got)
+	}
+}
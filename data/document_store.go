@@ -2,6 +2,7 @@ package data
 
 import (
 	"log/slog"
+	"os"
 	"sylmark/lsp"
 )
 
@@ -17,14 +18,23 @@ func (store *Store) RemoveDoc(id Id) (docData DocumentData, found bool) {
 		slog.Error("Store is empty")
 		return DocumentData{}, false
 	}
-	s := *store
+	s := store
+	s.forgetReadDoc(id)
 	// remove from DocStore
 	docData, found = s.DocStore[id]
 	if found {
+		docData.Trees.Close()
+		docData.Trees = nil
 		delete(s.DocStore, id)
 	}
+	delete(s.openDocs, id)
+	delete(s.semanticTokens, id)
 	// remove from gliGLinkStore
 	s.LinkStore.RemoveDef(id, "", lsp.Range{})
+	s.removeFrontMatterData(id)
+	delete(s.Tasks, id)
+	delete(s.docEntries, id)
+	s.markDefChanged(id, "")
 	return docData, found
 }
 
@@ -34,24 +44,21 @@ func (store *Store) AddUpdateDoc(id Id, docData *DocumentData) bool {
 		slog.Error("DocumentStore not defined")
 		return false
 	}
-	s := *store
+	s := store
 
+	s.forgetReadDoc(id)
 	s.DocStore[id] = *docData
 	return true
 }
 func (s *Store) GetDocMustTree(id Id, parse lsp.ParseFunction) (docData DocumentData, ok bool) {
-	docData, found := s.GetDoc(id)
-	if found {
-		if docData.Trees == nil {
-			docData.Trees = parse(string(docData.Content), nil)
-			s.AddUpdateDoc(id, &docData)
-		}
+	docData, found := s.DocStore[id]
+	if found && docData.Trees != nil {
 		return docData, true
 	}
-	return docData, false
+	return s.readDoc(id, parse)
 }
 
-func (s *Store) AddDoc(id Id) *DocumentData {
+func (s *Store) docFromDisk(id Id) *DocumentData {
 	uri, _ := s.GetUri(id)
 	path, err := PathFromURI(uri)
 	if err != nil {
@@ -59,19 +66,112 @@ func (s *Store) AddDoc(id Id) *DocumentData {
 		return nil
 	}
 	content := ContentFromDocPath(path)
-	fdocData := NewDocumentData(Document(content), nil)
-	s.DocStore[id] = *fdocData
-	return fdocData
+	return NewDocumentData(Document(content), nil)
 }
 
 func (s *Store) GetDoc(id Id) (docData DocumentData, ok bool) {
 	docData, found := s.DocStore[id]
+	if found {
+		return docData, true
+	}
+	return s.readDoc(id, nil)
+}
+
+// doc missing in DocStore or without trees there, trees are parsed when parse is given,
+// kept in readDocs as requests holding only the read lock share it
+func (s *Store) readDoc(id Id, parse lsp.ParseFunction) (docData DocumentData, ok bool) {
+	s.readDocsMu.Lock()
+	defer s.readDocsMu.Unlock()
+	docData, found := s.readDocs[id]
 	if !found {
-		doc := s.AddDoc(id)
+		docData, found = s.DocStore[id]
+	}
+	if !found {
+		doc := s.docFromDisk(id)
 		if doc == nil {
-			return
+			return docData, false
 		}
 		docData = *doc
 	}
+	if parse != nil && docData.Trees == nil {
+		docData.Trees = parse(string(docData.Content), nil)
+	}
+	s.readDocs[id] = docData
 	return docData, true
 }
+
+// what readers kept of doc is stale once it changes in DocStore
+func (s *Store) forgetReadDoc(id Id) {
+	s.readDocsMu.Lock()
+	defer s.readDocsMu.Unlock()
+	if docData, found := s.readDocs[id]; found {
+		docData.Trees.Close()
+		delete(s.readDocs, id)
+	}
+}
+
+// content from client, links are synced if it differs from what was loaded
+func (s *Store) OpenDoc(id Id, content string, parse lsp.ParseFunction) {
+	s.openDocs[id] = true
+	s.SyncChangedDocument(id, lsp.TextDocumentContentChangeEvent{Text: content}, parse)
+}
+
+// unsaved changes are discarded by syncing back to content on disk, only trees are released
+func (s *Store) CloseDoc(id Id, parse lsp.ParseFunction) {
+	delete(s.openDocs, id)
+	delete(s.semanticTokens, id)
+	docData, found := s.DocStore[id]
+	if !found {
+		return
+	}
+	uri, _ := s.GetUri(id)
+	if path, err := PathFromURI(uri); err == nil {
+		if content, err := os.ReadFile(path); err == nil && string(content) != string(docData.Content) {
+			s.SyncChangedDocument(id, lsp.TextDocumentContentChangeEvent{Text: string(content)}, parse)
+		}
+	}
+	s.releaseTrees(id)
+}
+
+func (s *Store) IsDocOpen(id Id) bool {
+	return s.openDocs[id]
+}
+
+// docs which are not open are read again from disk when needed
+func (s *Store) releaseDoc(id Id) {
+	s.forgetReadDoc(id)
+	docData, found := s.DocStore[id]
+	if !found {
+		return
+	}
+	docData.Trees.Close()
+	delete(s.DocStore, id)
+}
+
+// content stays so that closed docs aren't read from disk again, trees are parsed again when needed
+func (s *Store) releaseTrees(id Id) {
+	docData, found := s.DocStore[id]
+	if !found || docData.Trees == nil {
+		return
+	}
+	docData.Trees.Close()
+	docData.Trees = nil
+	s.DocStore[id] = docData
+}
+
+// drops trees parsed for closed docs while handling requests, docs read by then stay without trees
+func (s *Store) ReleaseClosedDocs() {
+	for id, docData := range s.readDocs {
+		docData.Trees.Close()
+		if _, found := s.DocStore[id]; !found {
+			docData.Trees = nil
+			s.DocStore[id] = docData
+		}
+	}
+	clear(s.readDocs)
+	for id := range s.DocStore {
+		if !s.openDocs[id] {
+			s.releaseTrees(id)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package data
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sylmark/lsp"
+	"testing"
+)
+
+func TestCloseAndReleaseDocs(t *testing.T) {
+	s, parse := newTestVault(t)
+	b := addTestNote(t, s, parse, "b.md", "# B\n")
+	a := addTestNote(t, s, parse, "a.md", "# A\n")
+
+	t.Run("1 Close drops unsaved changes and trees", func(t *testing.T) {
+		s.OpenDoc(a, "# A\n## Unsaved\n", parse)
+		if _, found := s.LinkStore.GetDef(a, "#Unsaved"); !found {
+			t.Fatalf("Def of #Unsaved should be loaded while open")
+		}
+		s.CloseDoc(a, parse)
+		doc, found := s.DocStore[a]
+		if !found || doc.Content != "# A\n" {
+			t.Errorf("Content >>> [# A] got [%s] %v", doc.Content, found)
+		}
+		if doc.Trees != nil {
+			t.Errorf("Trees of closed doc should be released")
+		}
+		if _, found := s.LinkStore.GetDef(a, "#Unsaved"); found {
+			t.Errorf("Def of #Unsaved should be gone after close")
+		}
+	})
+	t.Run("2 Release keeps content of closed docs", func(t *testing.T) {
+		s.OpenDoc(a, "# A\n", parse)
+		if _, ok := s.GetDocMustTree(b, parse); !ok {
+			t.Fatalf("b not found")
+		}
+		s.ReleaseClosedDocs()
+		if doc, found := s.DocStore[b]; !found || doc.Content != "# B\n" || doc.Trees != nil {
+			t.Errorf("Closed doc >>> content without trees got %v", doc)
+		}
+		if doc := s.DocStore[a]; doc.Trees == nil {
+			t.Errorf("Open doc should keep trees")
+		}
+	})
+	t.Run("3 Released doc is parsed again", func(t *testing.T) {
+		doc, ok := s.GetDocMustTree(b, parse)
+		if !ok || doc.Trees == nil || doc.Trees.GetMainTree().RootNode().HasError() {
+			t.Errorf("Trees of b should be parsed again")
+		}
+	})
+	t.Run("4 Changed on disk is read again", func(t *testing.T) {
+		path := filepath.Join(s.Config.RootPath, "b.md")
+		if err := os.WriteFile(path, []byte("# B\n## New\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		s.ReloadChangedDocs([]lsp.DocumentURI{testNoteURI(s, "b.md")}, parse)
+		if doc, _ := s.GetDoc(b); doc.Content != "# B\n## New\n" {
+			t.Errorf("Content >>> [# B ## New] got [%s]", doc.Content)
+		}
+		if _, found := s.LinkStore.GetDef(b, "#New"); !found {
+			t.Errorf("Def of #New should be loaded")
+		}
+	})
+}
+
+func TestReadsKeepStore(t *testing.T) {
+	s, parse := newTestVault(t)
+	a := addTestNote(t, s, parse, "a.md", "# A\n")
+	s.ReleaseClosedDocs()
+	ids := len(s.IdStore.Id)
+
+	t.Run("1 Lookups of missing notes create no ids", func(t *testing.T) {
+		if _, found := s.GetDefsFromTarget(context.Background(), "missing", ""); found {
+			t.Errorf("Defs of missing should not be found")
+		}
+		if _, found := s.GetRefsFromTarget(context.Background(), "dir/missing", "#Part"); found {
+			t.Errorf("Refs of dir/missing should not be found")
+		}
+		if len(s.IdStore.Id) != ids {
+			t.Errorf("Ids >>> %d got %d", ids, len(s.IdStore.Id))
+		}
+		if _, found := s.TargetStore["missing"]; found {
+			t.Errorf("Target missing should not be added")
+		}
+	})
+	t.Run("2 Trees of closed doc are kept aside till release", func(t *testing.T) {
+		doc, ok := s.GetDocMustTree(a, parse)
+		if !ok || doc.Trees == nil {
+			t.Fatalf("Trees of a should be parsed")
+		}
+		if s.DocStore[a].Trees != nil {
+			t.Errorf("DocStore should not get trees of closed doc")
+		}
+		s.ReleaseClosedDocs()
+		if len(s.readDocs) != 0 {
+			t.Errorf("Read docs >>> [] got %v", s.readDocs)
+		}
+	})
+	t.Run("3 Lookups stop once ctx is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, found := s.GetDefsFromTarget(ctx, "a", ""); found {
+			t.Errorf("Defs should not be found once ctx is done")
+		}
+		if _, found := s.GetDefsFromTarget(context.Background(), "a", ""); !found {
+			t.Errorf("Defs of a should be found")
+		}
+	})
+}
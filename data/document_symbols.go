@@ -0,0 +1,137 @@
+package data
+
+import (
+	"fmt"
+	"slices"
+	"sylmark/lsp"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+type documentSymbolNode struct {
+	symbol   lsp.DocumentSymbol
+	level    int
+	children []*documentSymbolNode
+}
+
+func comparePosition(a, b lsp.Position) int {
+	if a.Line != b.Line {
+		return a.Line - b.Line
+	}
+	return a.Character - b.Character
+}
+
+func rangeContains(rng lsp.Range, pos lsp.Position) bool {
+	return comparePosition(rng.Start, pos) <= 0 && comparePosition(pos, rng.End) < 0
+}
+
+func rangesOverlap(a, b lsp.Range) bool {
+	return comparePosition(a.Start, b.End) < 0 && comparePosition(b.Start, a.End) < 0
+}
+
+// adds symbol under the deepest heading containing it
+func (n *documentSymbolNode) insert(symbol lsp.DocumentSymbol) {
+	for _, child := range n.children {
+		if child.level > 0 && rangeContains(child.symbol.Range, symbol.Range.Start) {
+			child.insert(symbol)
+			return
+		}
+	}
+	n.children = append(n.children, &documentSymbolNode{symbol: symbol})
+}
+
+func (n *documentSymbolNode) toSymbols() []lsp.DocumentSymbol {
+	slices.SortStableFunc(n.children, func(a, b *documentSymbolNode) int {
+		return comparePosition(a.symbol.Range.Start, b.symbol.Range.Start)
+	})
+	symbols := []lsp.DocumentSymbol{}
+	for _, child := range n.children {
+		symbol := child.symbol
+		if len(child.children) > 0 {
+			symbol.Children = child.toSymbols()
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
+
+// outline of headings, a heading section goes till next heading of same or higher level
+// footnote definitions and tags are children of the heading they are in
+func (s *Store) GetDocumentSymbols(id Id, parse lsp.ParseFunction) []lsp.DocumentSymbol {
+	docData, ok := s.GetDocMustTree(id, parse)
+	if !ok {
+		return []lsp.DocumentSymbol{}
+	}
+	content := string(docData.Content)
+	rootNode := docData.Trees.GetMainTree().RootNode()
+	docEnd := lsp.GetRange(rootNode).End
+
+	var headings []*documentSymbolNode
+	lsp.TraverseNodeWith(rootNode, func(n *tree_sitter.Node) {
+		switch n.Kind() {
+		case "atx_heading", "setext_heading":
+			text, selectionRange, ok := GetHeadingContent(n, content)
+			level := GetHeadingLevel(n)
+			if !ok || level == 0 {
+				return
+			}
+			rng := lsp.GetRange(n)
+			rng.End = docEnd
+			headings = append(headings, &documentSymbolNode{
+				symbol: lsp.DocumentSymbol{
+					Name:           text,
+					Detail:         fmt.Sprintf("h%d", level),
+					Kind:           lsp.SymbolKindKey,
+					Range:          rng,
+					SelectionRange: selectionRange,
+				},
+				level: level,
+			})
+		}
+	})
+
+	// section ends where next heading of same or higher level starts
+	root := &documentSymbolNode{}
+	stack := []*documentSymbolNode{root}
+	for _, heading := range headings {
+		for len(stack) > 1 && stack[len(stack)-1].level >= heading.level {
+			stack[len(stack)-1].symbol.Range.End = heading.symbol.Range.Start
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1]
+		parent.children = append(parent.children, heading)
+		stack = append(stack, heading)
+	}
+
+	footNotes := docData.FootNotes
+	if footNotes == nil {
+		footNotes = s.GetLoadedFootNotesStore(id, parse)
+	}
+	for target, footNote := range *footNotes {
+		if footNote.Def == nil {
+			continue
+		}
+		root.insert(lsp.DocumentSymbol{
+			Name:           fmt.Sprintf("[%s]", target),
+			Detail:         footNote.Excert,
+			Kind:           lsp.SymbolKindVariable,
+			Range:          *footNote.Def,
+			SelectionRange: *footNote.Def,
+		})
+	}
+
+	lsp.TraverseNodeWith(docData.Trees.GetInlineTree().RootNode(), func(n *tree_sitter.Node) {
+		switch n.Kind() {
+		case "tag":
+			rng := lsp.GetRange(n)
+			root.insert(lsp.DocumentSymbol{
+				Name:           string(GetTag(n, content)),
+				Kind:           lsp.SymbolKindEnum,
+				Range:          rng,
+				SelectionRange: rng,
+			})
+		}
+	})
+
+	return root.toSymbols()
+}
@@ -0,0 +1,71 @@
+package data
+
+import (
+	"fmt"
+	"slices"
+	"sylmark/lsp"
+	"testing"
+)
+
+// "depth name start-end" of each symbol, depth first
+func flattenSymbols(symbols []lsp.DocumentSymbol, depth int) (flat []string) {
+	for _, symbol := range symbols {
+		rng := symbol.Range
+		flat = append(flat, fmt.Sprintf("%d %s %d:%d-%d:%d", depth, symbol.Name, rng.Start.Line, rng.Start.Character, rng.End.Line, rng.End.Character))
+		flat = append(flat, flattenSymbols(symbol.Children, depth+1)...)
+	}
+	return flat
+}
+
+func TestDocumentSymbols(t *testing.T) {
+	s, parse := newTestVault(t)
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"1 Sections end at next heading of same level", "# A\n\n## B\ntext\n\n## C\n\n# D\n", []string{
+			"0 A 0:0-7:0",
+			"1 B 2:0-5:0",
+			"1 C 5:0-7:0",
+			"0 D 7:0-8:0",
+		}},
+		{"2 Skipped levels nest under last higher one", "# A\n#### Deep\n## B\n", []string{
+			"0 A 0:0-3:0",
+			"1 Deep 1:0-2:0",
+			"1 B 2:0-3:0",
+		}},
+		{"3 Deeper heading before first one", "### C\n# A\n", []string{
+			"0 C 0:0-1:0",
+			"0 A 1:0-2:0",
+		}},
+		{"4 Note without headings", "text\n- item\n", nil},
+		{"5 Front matter before first heading", "---\ntitle: x\n---\n# A\ntext\n", []string{
+			"0 A 3:0-5:0",
+		}},
+		{"6 Last section ends at end without newline", "# A\n## B\ntext", []string{
+			"0 A 0:0-2:4",
+			"1 B 1:0-2:4",
+		}},
+		{"7 Setext heading", "Title\n=====\ntext\n## Sub\n", []string{
+			"0 Title 0:0-4:0",
+			"1 Sub 3:0-4:0",
+		}},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := addTestNote(t, s, parse, fmt.Sprintf("%d.md", i), tt.content)
+			if got := flattenSymbols(s.GetDocumentSymbols(id, parse), 0); !slices.Equal(got, tt.want) {
+				t.Errorf("Symbols >>> %v got %v", tt.want, got)
+			}
+		})
+	}
+	t.Run("8 Selection range is heading text", func(t *testing.T) {
+		id := addTestNote(t, s, parse, "selection.md", "## Part One\n")
+		symbols := s.GetDocumentSymbols(id, parse)
+		want := lsp.Range{Start: lsp.Position{Character: 3}, End: lsp.Position{Character: 11}}
+		if len(symbols) != 1 || symbols[0].SelectionRange != want || symbols[0].Detail != "h2" {
+			t.Errorf("Symbol >>> h2 at %v got %+v", want, symbols)
+		}
+	})
+}
@@ -0,0 +1,232 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sylmark/lsp"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// ![[note]], ![[note#heading]] and ![[#^block]], alias after | is not part of the target
+var embedRegex = regexp.MustCompile(`!\[\[([^\[\]\n]+)\]\]`)
+
+type embed struct {
+	target    Target
+	subTarget SubTarget
+	// from ! till closing brackets
+	rng lsp.Range
+}
+
+// wiki_link right after ! is taken by embeds
+func isEmbedNode(n *tree_sitter.Node, content string) bool {
+	start := n.StartByte()
+	return start > 0 && start <= uint(len(content)) && content[start-1] == '!'
+}
+
+func getEmbedTargets(dest string) (Target, SubTarget) {
+	dest, _, _ = strings.Cut(dest, "|")
+	target, subTarget, found := strings.Cut(dest, "#")
+	if !found {
+		return Target(strings.TrimSpace(dest)), ""
+	}
+	return Target(strings.TrimSpace(target)), SubTarget("#" + subTarget)
+}
+
+// code blocks, code spans and front matter keep embeds as plain text
+func isCodeAt(trees *lsp.Trees, row uint, column uint) bool {
+	point := tree_sitter.Point{Row: row, Column: column}
+	for n := trees.GetMainTree().RootNode().NamedDescendantForPointRange(point, point); n != nil; n = n.Parent() {
+		switch n.Kind() {
+		case "fenced_code_block", "indented_code_block", "html_block", "minus_metadata", "plus_metadata":
+			return true
+		}
+	}
+	for n := trees.GetInlineTree().RootNode().NamedDescendantForPointRange(point, point); n != nil; n = n.Parent() {
+		if n.Kind() == "code_span" {
+			return true
+		}
+	}
+	return false
+}
+
+// grammar has no embed node, they are found in text like block ids
+func getEmbeds(content string, trees *lsp.Trees, span lineSpan) (embeds []embed) {
+	forLinesWithin(content, span, func(i int, line string) {
+		for _, match := range embedRegex.FindAllStringSubmatchIndex(line, -1) {
+			if isCodeAt(trees, uint(i), uint(match[0])) {
+				continue
+			}
+			target, subTarget := getEmbedTargets(line[match[2]:match[3]])
+			embeds = append(embeds, embed{
+				target:    target,
+				subTarget: subTarget,
+				rng: lsp.Range{
+					Start: lsp.Position{Line: i, Character: match[0]},
+					End:   lsp.Position{Line: i, Character: match[1]},
+				},
+			})
+		}
+	})
+	return embeds
+}
+
+// embed under pos, target is empty for ones within file
+func (s *Store) GetEmbedAt(id Id, pos lsp.Position, parse lsp.ParseFunction) (target Target, subTarget SubTarget, rng lsp.Range, ok bool) {
+	doc, found := s.GetDocMustTree(id, parse)
+	if !found {
+		return "", "", rng, false
+	}
+	line := doc.Content.GetLine(pos.Line)
+	for _, match := range embedRegex.FindAllStringSubmatchIndex(line, -1) {
+		if pos.Character < match[0] || pos.Character > match[1] {
+			continue
+		}
+		if isCodeAt(doc.Trees, uint(pos.Line), uint(match[0])) {
+			return "", "", rng, false
+		}
+		target, subTarget = getEmbedTargets(line[match[2]:match[3]])
+		return target, subTarget, lsp.Range{
+			Start: lsp.Position{Line: pos.Line, Character: match[0]},
+			End:   lsp.Position{Line: pos.Line, Character: match[1]},
+		}, true
+	}
+	return "", "", rng, false
+}
+
+// notes and places embedded, id itself for embeds within file
+func (s *Store) GetEmbedDefs(ctx context.Context, id Id, target Target, subTarget SubTarget) []IdLocation {
+	if len(target) == 0 {
+		rng, found := s.LinkStore.GetDef(id, subTarget)
+		if !found {
+			return nil
+		}
+		return []IdLocation{{Id: id, Range: rng}}
+	}
+	defs, _ := s.GetDefsFromTarget(ctx, target, subTarget)
+	return defs
+}
+
+// lines of whole note without front matter, heading section till next heading of same or higher level, or block
+func (s *Store) getEmbedLines(id Id, subTarget SubTarget, parse lsp.ParseFunction) ([]string, bool) {
+	docData, ok := s.GetDocMustTree(id, parse)
+	if !ok {
+		return nil, false
+	}
+	content := string(docData.Content)
+	lines := strings.Split(content, "\n")
+	start, end := 0, len(lines)
+
+	switch {
+	case len(subTarget) == 0:
+		if fm, found := ParseFrontMatter(content); found {
+			start = fm.Range.End.Line + 1
+		}
+	case IsBlockSubTarget(subTarget):
+		rng, found := s.LinkStore.GetDef(id, subTarget)
+		if !found {
+			return nil, false
+		}
+		start, end = rng.Start.Line, rng.End.Line+1
+	default:
+		rng, found := s.LinkStore.GetDef(id, subTarget)
+		if !found {
+			return nil, false
+		}
+		start = rng.Start.Line
+		level := 0
+		lsp.TraverseNodeWith(docData.Trees.GetMainTree().RootNode(), func(n *tree_sitter.Node) {
+			if n.Kind() != "atx_heading" && n.Kind() != "setext_heading" {
+				return
+			}
+			line := int(n.StartPosition().Row)
+			if line == start {
+				level = GetHeadingLevel(n)
+			} else if level > 0 && line > start && line < end && GetHeadingLevel(n) <= level {
+				end = line
+			}
+		})
+	}
+
+	if start >= len(lines) || start >= end {
+		return nil, false
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+	embedded := lines[start:end]
+	for len(embedded) > 0 && len(strings.TrimSpace(embedded[len(embedded)-1])) == 0 {
+		embedded = embedded[:len(embedded)-1]
+	}
+	return embedded, len(embedded) > 0
+}
+
+// embedded part in full unlike excerpts of links
+func (s *Store) GetEmbedContent(id Id, subTarget SubTarget, parse lsp.ParseFunction) (string, bool) {
+	lines, ok := s.getEmbedLines(id, subTarget, parse)
+	if !ok {
+		return "", false
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+// replaces embed under pos with what it embeds, block ids are left behind so that they stay unique
+func (s *Store) GetEmbedExpandEdit(ctx context.Context, id Id, pos lsp.Position, parse lsp.ParseFunction) (edit lsp.WorkspaceEdit, err error) {
+	target, subTarget, rng, ok := s.GetEmbedAt(id, pos, parse)
+	if !ok {
+		return edit, fmt.Errorf("No embed found")
+	}
+	name := string(target) + string(subTarget)
+	defs := s.GetEmbedDefs(ctx, id, target, subTarget)
+	switch len(defs) {
+	case 0:
+		return edit, fmt.Errorf("Embed `%s` is unresolved", name)
+	case 1:
+	default:
+		return edit, fmt.Errorf("Embed `%s` matches %d notes", name, len(defs))
+	}
+	lines, ok := s.getEmbedLines(defs[0].Id, subTarget, parse)
+	if !ok {
+		return edit, fmt.Errorf("Embed `%s` is empty", name)
+	}
+	expanded := make([]string, len(lines))
+	for i, line := range lines {
+		if match := blockIdRegex.FindStringSubmatchIndex(line); match != nil {
+			line = strings.TrimRight(line[:match[2]], " \t")
+		}
+		expanded[i] = line
+	}
+
+	uri, _ := s.GetUri(id)
+	edit.Changes = map[lsp.DocumentURI][]lsp.TextEdit{
+		uri: {{
+			Range:   rng,
+			NewText: strings.Join(expanded, "\n"),
+		}},
+	}
+	return edit, nil
+}
+
+// link_destination range of embed at rng, the part between [[ and | or ]]
+func (s *Store) getEmbedDestinationAt(id Id, rng lsp.Range) (dest string, destRng lsp.Range, ok bool) {
+	doc, found := s.GetDoc(id)
+	if !found {
+		return "", destRng, false
+	}
+	line := doc.Content.GetLine(rng.Start.Line)
+	if rng.Start.Line != rng.End.Line || rng.End.Character > len(line) || rng.Start.Character > rng.End.Character {
+		return "", destRng, false
+	}
+	match := embedRegex.FindStringSubmatchIndex(line[rng.Start.Character:rng.End.Character])
+	if match == nil || match[0] != 0 {
+		return "", destRng, false
+	}
+	dest, _, _ = strings.Cut(line[rng.Start.Character+match[2]:rng.Start.Character+match[3]], "|")
+	destRng = lsp.Range{
+		Start: lsp.Position{Line: rng.Start.Line, Character: rng.Start.Character + match[2]},
+		End:   lsp.Position{Line: rng.Start.Line, Character: rng.Start.Character + match[2] + len(dest)},
+	}
+	return dest, destRng, true
+}
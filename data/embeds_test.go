@@ -0,0 +1,111 @@
+package data
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sylmark/lsp"
+	"testing"
+)
+
+func TestEmbedContent(t *testing.T) {
+	s, parse := newTestVault(t)
+	b := addTestNote(t, s, parse, "b.md", strings.Join([]string{
+		"---",
+		"title: B",
+		"---",
+		"# B",
+		"intro",
+		"## Part",
+		"one",
+		"### Sub",
+		"two",
+		"",
+		"## Next",
+		"three ^blk",
+		"",
+	}, "\n"))
+
+	t.Run("1 Whole note without front matter", func(t *testing.T) {
+		got, _ := s.GetEmbedContent(b, "", parse)
+		if !strings.HasPrefix(got, "# B\nintro") || !strings.HasSuffix(got, "three ^blk") {
+			t.Errorf("Content >>> [# B ... three ^blk] got [%s]", got)
+		}
+	})
+	t.Run("2 Section ends before heading of same level", func(t *testing.T) {
+		want := "## Part\none\n### Sub\ntwo"
+		if got, _ := s.GetEmbedContent(b, "#Part", parse); got != want {
+			t.Errorf("Content >>> [%s] got [%s]", want, got)
+		}
+	})
+	t.Run("3 Last section runs till end", func(t *testing.T) {
+		want := "## Next\nthree ^blk"
+		if got, _ := s.GetEmbedContent(b, "#Next", parse); got != want {
+			t.Errorf("Content >>> [%s] got [%s]", want, got)
+		}
+	})
+	t.Run("4 Block", func(t *testing.T) {
+		want := "three ^blk"
+		if got, _ := s.GetEmbedContent(b, "#^blk", parse); got != want {
+			t.Errorf("Content >>> [%s] got [%s]", want, got)
+		}
+	})
+	t.Run("5 Unknown heading", func(t *testing.T) {
+		if got, ok := s.GetEmbedContent(b, "#Missing", parse); ok {
+			t.Errorf("Content >>> [] got [%s]", got)
+		}
+	})
+}
+
+func TestEmbedExpand(t *testing.T) {
+	s, parse := newTestVault(t)
+	addTestNote(t, s, parse, "b.md", "# B\nfirst ^one\n\nsecond\n")
+	a := addTestNote(t, s, parse, "a.md", "![[b]]\n![[b#^one]]\n![[nope]]\nno embed\n")
+
+	t.Run("1 Block ids are left behind", func(t *testing.T) {
+		edit, err := s.GetEmbedExpandEdit(context.Background(), a, lsp.Position{Line: 0, Character: 2}, parse)
+		if err != nil {
+			t.Fatalf("GetEmbedExpandEdit failed %s", err)
+		}
+		edits := edit.Changes[testNoteURI(s, "a.md")]
+		if len(edits) != 1 || edits[0].NewText != "# B\nfirst\n\nsecond" {
+			t.Fatalf("Edits >>> [# B first second] got %v", edits)
+		}
+		wantRng := lsp.Range{End: lsp.Position{Line: 0, Character: 6}}
+		if edits[0].Range != wantRng {
+			t.Errorf("Range >>> %v got %v", wantRng, edits[0].Range)
+		}
+	})
+	t.Run("2 Block embed", func(t *testing.T) {
+		edit, err := s.GetEmbedExpandEdit(context.Background(), a, lsp.Position{Line: 1, Character: 0}, parse)
+		if err != nil {
+			t.Fatalf("GetEmbedExpandEdit failed %s", err)
+		}
+		if edits := edit.Changes[testNoteURI(s, "a.md")]; len(edits) != 1 || edits[0].NewText != "first" {
+			t.Errorf("Edits >>> [first] got %v", edits)
+		}
+	})
+	t.Run("3 Unresolved and missing embeds fail", func(t *testing.T) {
+		if _, err := s.GetEmbedExpandEdit(context.Background(), a, lsp.Position{Line: 2, Character: 3}, parse); err == nil {
+			t.Errorf("Unresolved embed should fail")
+		}
+		if _, err := s.GetEmbedExpandEdit(context.Background(), a, lsp.Position{Line: 3, Character: 3}, parse); err == nil {
+			t.Errorf("Line without embed should fail")
+		}
+	})
+}
+
+func TestEmbedsInCode(t *testing.T) {
+	parse := newTestParse(t)
+	content := "`![[b]]` and ![[c#Part]]\n\n```\n![[d]]\n```\n"
+	trees := parse(content, nil)
+	defer trees.Close()
+
+	var got []Target
+	for _, e := range getEmbeds(content, trees, allLines) {
+		got = append(got, e.target)
+	}
+	if want := []Target{"c"}; !slices.Equal(want, got) {
+		t.Errorf("Embeds >>> %v got %v", want, got)
+	}
+}
@@ -0,0 +1,67 @@
+package data
+
+import (
+	"sylmark/lsp"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// last line having content of node, nodes usually end at start of next line
+func getFoldEndLine(pos lsp.Position) int {
+	if pos.Character == 0 && pos.Line > 0 {
+		return pos.Line - 1
+	}
+	return pos.Line
+}
+
+func appendFold(folds []lsp.FoldingRange, startLine int, endLine int, kind lsp.FoldingRangeKind) []lsp.FoldingRange {
+	if endLine <= startLine {
+		return folds
+	}
+	return append(folds, lsp.FoldingRange{
+		StartLine: startLine,
+		EndLine:   endLine,
+		Kind:      kind,
+	})
+}
+
+// folds heading sections till next heading of same or higher level, list items, code blocks, quotes and front matter
+func (s *Store) GetFoldingRanges(id Id, parse lsp.ParseFunction) []lsp.FoldingRange {
+	folds := []lsp.FoldingRange{}
+	docData, ok := s.GetDocMustTree(id, parse)
+	if !ok {
+		return folds
+	}
+	rootNode := docData.Trees.GetMainTree().RootNode()
+	docEndLine := getFoldEndLine(lsp.GetRange(rootNode).End)
+
+	type openSection struct {
+		startLine int
+		level     int
+	}
+	var sections []openSection
+
+	lsp.TraverseNodeWith(rootNode, func(n *tree_sitter.Node) {
+		switch n.Kind() {
+		case "atx_heading", "setext_heading":
+			level := GetHeadingLevel(n)
+			if level == 0 {
+				return
+			}
+			startLine := int(n.StartPosition().Row)
+			for len(sections) > 0 && sections[len(sections)-1].level >= level {
+				folds = appendFold(folds, sections[len(sections)-1].startLine, startLine-1, lsp.FoldingRangeKindRegion)
+				sections = sections[:len(sections)-1]
+			}
+			sections = append(sections, openSection{startLine: startLine, level: level})
+		case "list_item", "fenced_code_block", "block_quote", "minus_metadata", "plus_metadata":
+			rng := lsp.GetRange(n)
+			folds = appendFold(folds, rng.Start.Line, getFoldEndLine(rng.End), "")
+		}
+	})
+	for i := len(sections) - 1; i >= 0; i-- {
+		folds = appendFold(folds, sections[i].startLine, docEndLine, lsp.FoldingRangeKindRegion)
+	}
+
+	return folds
+}
@@ -0,0 +1,39 @@
+package data
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestFoldingRanges(t *testing.T) {
+	s, parse := newTestVault(t)
+	tests := []struct {
+		name    string
+		content string
+		// "start-end kind" sorted
+		want []string
+	}{
+		{"1 Nested sections", "# A\ntext\n## B\ntext\n# C\ntext\n", []string{"0-3 region", "2-3 region", "4-5 region"}},
+		{"2 Section at end without newline", "# A\ntext", []string{"0-1 region"}},
+		{"3 Open sections end at last line", "# A\ntext\n\n## B\n\n", []string{"0-4 region", "3-4 region"}},
+		{"4 Heading alone folds nothing", "# A\n# B\n", nil},
+		{"5 Lists", "- one\n  more\n- two\n  - nested\n    more\n", []string{"0-1 ", "2-4 ", "3-4 "}},
+		{"6 Fenced code", "text\n\n```go\ncode\n```\n", []string{"2-4 "}},
+		{"7 Front matter", "---\ntitle: x\n---\n# A\ntext\n", []string{"0-2 ", "3-4 region"}},
+		{"8 Quote", "> one\n> two\n", []string{"0-1 "}},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := addTestNote(t, s, parse, fmt.Sprintf("%d.md", i), tt.content)
+			var got []string
+			for _, fold := range s.GetFoldingRanges(id, parse) {
+				got = append(got, fmt.Sprintf("%d-%d %s", fold.StartLine, fold.EndLine, fold.Kind))
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Folds >>> %v got %v", tt.want, got)
+			}
+		})
+	}
+}
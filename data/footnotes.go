@@ -44,6 +44,7 @@ func (fs *FootNotesStore) AddDefRef(target string, isDefinition bool, rng lsp.Ra
 func (fs *FootNotesStore) GetFootNote(target string) (FootNoteRef, bool) {
 	if fs == nil {
 		slog.Error("FootNotesStore is nil")
+		return FootNoteRef{}, false
 	}
 	s := *fs
 	r, ok := s[target]
@@ -0,0 +1,249 @@
+package data
+
+import (
+	"strings"
+	"sylmark/lsp"
+)
+
+type FrontMatterFormat string
+
+const (
+	FrontMatterYAML FrontMatterFormat = "yaml"
+	FrontMatterTOML FrontMatterFormat = "toml"
+)
+
+// scalar or item of a list, Range is without quotes
+type FrontMatterValue struct {
+	Text  string
+	Range lsp.Range
+}
+
+type FrontMatterField struct {
+	Key      string
+	KeyRange lsp.Range
+	IsList   bool
+	Values   []FrontMatterValue
+}
+
+// top level fields of --- yaml or +++ toml block at start of note, nested maps and tables are skipped
+type FrontMatter struct {
+	Format FrontMatterFormat
+	// delimiter lines included
+	Range  lsp.Range
+	Fields []FrontMatterField
+}
+
+func (fm *FrontMatter) GetField(key string) (*FrontMatterField, bool) {
+	for i := range fm.Fields {
+		if strings.EqualFold(fm.Fields[i].Key, key) {
+			return &fm.Fields[i], true
+		}
+	}
+	return nil, false
+}
+
+// values of field, a scalar is a list of one
+func (fm *FrontMatter) GetValues(key string) []FrontMatterValue {
+	field, ok := fm.GetField(key)
+	if !ok {
+		return nil
+	}
+	return field.Values
+}
+
+// line is within front matter including delimiters
+func (fm *FrontMatter) ContainsLine(line int) bool {
+	return line >= fm.Range.Start.Line && line <= fm.Range.End.Line
+}
+
+// columns are bytes as everywhere in stores
+func ParseFrontMatter(content string) (fm FrontMatter, ok bool) {
+	lines := strings.Split(content, "\n")
+	if len(lines) < 2 {
+		return fm, false
+	}
+	var closers []string
+	switch strings.TrimRight(lines[0], " \t\r") {
+	case "---":
+		fm.Format = FrontMatterYAML
+		closers = []string{"---", "..."}
+	case "+++":
+		fm.Format = FrontMatterTOML
+		closers = []string{"+++"}
+	default:
+		return fm, false
+	}
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		trimmed := strings.TrimRight(lines[i], " \t\r")
+		if trimmed == closers[0] || (len(closers) > 1 && trimmed == closers[1]) {
+			end = i
+			break
+		}
+	}
+	if end == -1 {
+		return fm, false
+	}
+	fm.Range = lsp.Range{
+		Start: lsp.Position{Line: 0, Character: 0},
+		End:   lsp.Position{Line: end, Character: len(strings.TrimRight(lines[end], "\r"))},
+	}
+
+	body := make([]string, end)
+	for i := 1; i < end; i++ {
+		body[i] = strings.TrimRight(lines[i], "\r")
+	}
+	if fm.Format == FrontMatterYAML {
+		fm.Fields = parseYAMLFields(body)
+	} else {
+		fm.Fields = parseTOMLFields(body)
+	}
+	return fm, true
+}
+
+// key: value, key: [a, b] and key: followed by - item lines
+func parseYAMLFields(lines []string) (fields []FrontMatterField) {
+	var field *FrontMatterField
+	for i := 1; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if len(trimmed) == 0 || trimmed[0] == '#' {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' || line[0] == '-' {
+			// block list of last key
+			if field == nil || !strings.HasPrefix(trimmed, "-") {
+				continue
+			}
+			dash := strings.IndexRune(line, '-')
+			if dash+1 < len(line) && line[dash+1] != ' ' && line[dash+1] != '\t' {
+				continue
+			}
+			field.IsList = true
+			if value, ok := getFrontMatterValue(line[dash+1:], dash+1, i, true); ok {
+				field.Values = append(field.Values, value)
+			}
+			continue
+		}
+
+		colon := strings.IndexRune(line, ':')
+		if colon == -1 {
+			field = nil
+			continue
+		}
+		key := strings.TrimRight(line[:colon], " \t")
+		fields = append(fields, FrontMatterField{
+			Key:      strings.Trim(key, `"'`),
+			KeyRange: lsp.Range{Start: lsp.Position{Line: i, Character: 0}, End: lsp.Position{Line: i, Character: len(key)}},
+		})
+		field = &fields[len(fields)-1]
+		rest := line[colon+1:]
+		if strings.HasPrefix(strings.TrimSpace(rest), "[") {
+			field.IsList = true
+			field.Values, i = getFrontMatterList(lines, i, colon+1+strings.IndexRune(rest, '['))
+			continue
+		}
+		if value, ok := getFrontMatterValue(rest, colon+1, i, true); ok {
+			field.Values = append(field.Values, value)
+		}
+	}
+	return fields
+}
+
+// key = value and key = [ "a", "b" ] until first [table]
+func parseTOMLFields(lines []string) (fields []FrontMatterField) {
+	for i := 1; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if len(trimmed) == 0 || trimmed[0] == '#' {
+			continue
+		}
+		if trimmed[0] == '[' {
+			break
+		}
+		eq := strings.IndexRune(line, '=')
+		if eq == -1 {
+			continue
+		}
+		keyStart := len(line) - len(strings.TrimLeft(line, " \t"))
+		key := strings.TrimRight(line[keyStart:eq], " \t")
+		field := FrontMatterField{
+			Key:      strings.Trim(key, `"'`),
+			KeyRange: lsp.Range{Start: lsp.Position{Line: i, Character: keyStart}, End: lsp.Position{Line: i, Character: keyStart + len(key)}},
+		}
+		rest := line[eq+1:]
+		if strings.HasPrefix(strings.TrimSpace(rest), "[") {
+			field.IsList = true
+			field.Values, i = getFrontMatterList(lines, i, eq+1+strings.IndexRune(rest, '['))
+		} else if value, ok := getFrontMatterValue(rest, eq+1, i, false); ok {
+			field.Values = append(field.Values, value)
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// items of [a, "b"] starting at open bracket, may span lines, returns line where it ends
+func getFrontMatterList(lines []string, line int, open int) (values []FrontMatterValue, lastLine int) {
+	col := open + 1
+	for ; line < len(lines); line, col = line+1, 0 {
+		text := lines[line]
+		var quote byte
+		start := col
+		for j := col; j < len(text); j++ {
+			c := text[j]
+			switch {
+			case quote != 0:
+				if c == quote {
+					quote = 0
+				}
+			case c == '"' || c == '\'':
+				quote = c
+			case c == ',' || c == ']':
+				if value, ok := getFrontMatterValue(text[start:j], start, line, false); ok {
+					values = append(values, value)
+				}
+				if c == ']' {
+					return values, line
+				}
+				start = j + 1
+			}
+		}
+		if value, ok := getFrontMatterValue(text[start:], start, line, false); ok {
+			values = append(values, value)
+		}
+	}
+	return values, len(lines) - 1
+}
+
+// trims spaces and quotes of raw which starts at col, unquoted values may end with a comment
+func getFrontMatterValue(raw string, col int, line int, stripComment bool) (value FrontMatterValue, ok bool) {
+	start := col + len(raw) - len(strings.TrimLeft(raw, " \t"))
+	text := strings.TrimSpace(raw)
+	if len(text) == 0 {
+		return value, false
+	}
+	if c := text[0]; (c == '"' || c == '\'') && len(text) > 1 {
+		if closing := strings.IndexByte(text[1:], c); closing != -1 {
+			text = text[1 : closing+1]
+			start++
+		}
+	} else if c == '#' {
+		// nothing but a comment
+		return value, false
+	} else if stripComment {
+		if i := strings.Index(text, " #"); i != -1 {
+			text = strings.TrimSpace(text[:i])
+		}
+	}
+	if len(text) == 0 {
+		return value, false
+	}
+	return FrontMatterValue{
+		Text: text,
+		Range: lsp.Range{
+			Start: lsp.Position{Line: line, Character: start},
+			End:   lsp.Position{Line: line, Character: start + len(text)},
+		},
+	}, true
+}
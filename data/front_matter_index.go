@@ -0,0 +1,231 @@
+package data
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// alias and tag are older spellings still found in vaults
+var (
+	aliasesFrontMatterKeys = []string{"aliases", "alias"}
+	tagsFrontMatterKeys    = []string{"tags", "tag"}
+)
+
+func getFrontMatterEntries(fm FrontMatter) (entries []docEntry) {
+	for _, key := range aliasesFrontMatterKeys {
+		for _, value := range fm.GetValues(key) {
+			entries = append(entries, docEntry{
+				Kind:   "alias",
+				Range:  value.Range,
+				Target: Target(value.Text),
+			})
+		}
+	}
+	for _, key := range tagsFrontMatterKeys {
+		for _, value := range fm.GetValues(key) {
+			if tag, ok := NewTag(value.Text); ok {
+				entries = append(entries, docEntry{
+					Kind:  "tag",
+					Range: value.Range,
+					Tag:   tag,
+					// as written, renames keep leaving out #
+					Value: value.Text,
+				})
+			}
+		}
+	}
+	if values := fm.GetValues("title"); len(values) > 0 {
+		entries = append(entries, docEntry{
+			Kind:  "title",
+			Range: values[0].Range,
+			Value: values[0].Text,
+		})
+	}
+	for _, field := range fm.Fields {
+		entries = append(entries, docEntry{
+			Kind:  "front_matter_key",
+			Range: field.KeyRange,
+			Key:   field.Key,
+		})
+		for _, value := range field.Values {
+			entries = append(entries, docEntry{
+				Kind:  "front_matter_value",
+				Range: value.Range,
+				Key:   field.Key,
+				Value: value.Text,
+			})
+		}
+	}
+	return entries
+}
+
+// alias same as one of file's own targets is nothing to register
+func (s *Store) isOwnTarget(id Id, target Target) bool {
+	uri, ok := s.GetUri(id)
+	if !ok {
+		return false
+	}
+	vaultTarget, _ := s.GetVaultTarget(uri)
+	oneUpTarget, _ := GetOneUpTarget(vaultTarget)
+	plainTarget, _ := GetPlainTarget(vaultTarget)
+	return target == vaultTarget || target == oneUpTarget || target == plainTarget
+}
+
+// links waiting on a shadow id belong to id from now on
+func (s *Store) claimShadowRefs(shadowId Id, id Id) {
+	if link, found := s.LinkStore[shadowId]; found {
+		for subTarget, refs := range link.Refs {
+			for _, loc := range refs {
+				s.LinkStore.AddRef(id, subTarget, loc)
+			}
+		}
+		delete(s.LinkStore, shadowId)
+	}
+}
+
+// [[alias]] resolves to id like it's own targets do
+func (s *Store) addAlias(id Id, alias Target) {
+	if len(alias) == 0 || s.isOwnTarget(id, alias) {
+		return
+	}
+	s.aliases[id] = append(s.aliases[id], alias)
+	ids := s.TargetStore[alias]
+	if shadowId, isShadow := s.IdStore.findShadowId(ids); isShadow {
+		s.claimShadowRefs(shadowId, id)
+		s.TargetStore[alias] = slices.DeleteFunc(slices.Clone(ids), func(i Id) bool {
+			return i == shadowId
+		})
+		targets := slices.DeleteFunc(s.IdStore.ShadowTargets[shadowId], func(t Target) bool {
+			return t == alias
+		})
+		if len(targets) == 0 {
+			s.IdStore.removeShadow(shadowId)
+		} else {
+			s.IdStore.ShadowTargets[shadowId] = targets
+		}
+	}
+	s.addTargetEntry(alias, id)
+	s.markDefChanged(id, "")
+}
+
+// target written in wiki link or embed at loc, refs only know where links are
+func (s *Store) getRefTarget(loc IdLocation) (Target, bool) {
+	doc, found := s.GetDoc(loc.Id)
+	rng := loc.Range
+	if !found || rng.Start.Line != rng.End.Line {
+		return "", false
+	}
+	line := doc.Content.GetLine(rng.Start.Line)
+	if rng.End.Character > len(line) || rng.Start.Character > rng.End.Character {
+		return "", false
+	}
+	text := line[rng.Start.Character:rng.End.Character]
+	if match := embedRegex.FindStringSubmatchIndex(text); match != nil && match[0] == 0 && match[1] == len(text) {
+		target, _ := getEmbedTargets(text[match[2]:match[3]])
+		return target, true
+	}
+	dest, found := strings.CutPrefix(text, "[[")
+	if !found {
+		return "", false
+	}
+	dest, found = strings.CutSuffix(dest, "]]")
+	if !found {
+		return "", false
+	}
+	dest, _, _ = strings.Cut(dest, "|")
+	target, _, _ := strings.Cut(dest, "#")
+	return Target(target), true
+}
+
+// links made through alias wait on a shadow id again, reverse of claimShadowRefs
+func (s *Store) releaseAliasRefs(id Id, alias Target) {
+	link, found := s.LinkStore[id]
+	if !found {
+		return
+	}
+	var moved []IdLocation
+	var subTargets []SubTarget
+	for subTarget, refs := range link.Refs {
+		for _, loc := range refs {
+			if target, ok := s.getRefTarget(loc); ok && target == alias {
+				moved = append(moved, loc)
+				subTargets = append(subTargets, subTarget)
+			}
+		}
+	}
+	if len(moved) == 0 {
+		return
+	}
+	// other notes having same alias hold these refs already
+	shadowId, isShadow := s.IdStore.findShadowId(s.getIds(alias))
+	for i, loc := range moved {
+		s.LinkStore.RemoveRef(id, subTargets[i], loc)
+		if isShadow {
+			s.LinkStore.AddRef(shadowId, subTargets[i], loc)
+		}
+	}
+	if isShadow {
+		s.markDefChanged(shadowId, "")
+	}
+}
+
+// links made through alias resolve like links to any other missing note
+func (s *Store) removeAlias(id Id, alias Target) {
+	aliases := s.aliases[id]
+	i := slices.Index(aliases, alias)
+	if i == -1 {
+		return
+	}
+	aliases = slices.Delete(aliases, i, i+1)
+	if len(aliases) == 0 {
+		delete(s.aliases, id)
+	} else {
+		s.aliases[id] = aliases
+	}
+	// same alias written twice
+	if slices.Contains(aliases, alias) {
+		return
+	}
+	ids := slices.DeleteFunc(slices.Clone(s.TargetStore[alias]), func(i Id) bool {
+		return i == id
+	})
+	if len(ids) == 0 {
+		delete(s.TargetStore, alias)
+	} else {
+		s.TargetStore[alias] = ids
+	}
+	s.releaseAliasRefs(id, alias)
+	s.markDefChanged(id, "")
+}
+
+// used when front matter content is not known anymore
+func (s *Store) removeFrontMatterData(id Id) {
+	for _, alias := range slices.Clone(s.aliases[id]) {
+		s.removeAlias(id, alias)
+	}
+	delete(s.titles, id)
+}
+
+func (s *Store) GetTitle(id Id) (string, bool) {
+	title, found := s.titles[id]
+	return title, found
+}
+
+func (s *Store) GetAliases(id Id) []Target {
+	return slices.Compact(slices.Sorted(slices.Values(s.aliases[id])))
+}
+
+// what alias points to or title of the note, shown along wikilink completions
+func (s *Store) getTargetDetail(target Target, ids []Id) string {
+	if len(ids) != 1 {
+		return ""
+	}
+	id := ids[0]
+	if slices.Contains(s.aliases[id], target) {
+		uri, _ := s.GetUri(id)
+		vaultTarget, _ := s.GetVaultTarget(uri)
+		return fmt.Sprintf("Alias of %s", vaultTarget)
+	}
+	return s.titles[id]
+}
@@ -0,0 +1,97 @@
+package data
+
+import (
+	"slices"
+	"sylmark/lsp"
+	"testing"
+)
+
+func newAliasTestStore() *Store {
+	s := NewStore()
+	s.Config.RootPath = "/vault"
+	return &s
+}
+
+func lineRange(line int) lsp.Range {
+	return lsp.Range{
+		Start: lsp.Position{Line: line, Character: 0},
+		End:   lsp.Position{Line: line, Character: 10},
+	}
+}
+
+func TestAliasShadow(t *testing.T) {
+	t.Run("1 Alias claims refs and removes shadow", func(t *testing.T) {
+		s := newAliasTestStore()
+		b := s.GetIdFromURI("file:///vault/b.md")
+		// [[My Alias]] in b before any note declares it
+		loc := IdLocation{Id: b, Range: lineRange(1)}
+		for _, id := range s.getIds("My Alias") {
+			s.LinkStore.AddRef(id, "", loc)
+		}
+		shadowId := s.TargetStore["My Alias"][0]
+
+		a := s.GetIdFromURI("file:///vault/a.md")
+		s.addAlias(a, "My Alias")
+
+		if _, found := s.IdStore.ShadowTargets[shadowId]; found {
+			t.Errorf("ShadowTargets of claimed shadow %d should be deleted", shadowId)
+		}
+		if _, found := s.IdStore.Id[shadowId]; found {
+			t.Errorf("Id of claimed shadow %d should be deleted", shadowId)
+		}
+		if ids := s.TargetStore["My Alias"]; !slices.Equal(ids, []Id{a}) {
+			t.Errorf("TargetStore >>> [%d] got %v", a, ids)
+		}
+		if refs, _ := s.LinkStore.GetRefs(a, ""); !slices.Contains(refs, loc) {
+			t.Errorf("Refs of a >>> %v got %v", loc, refs)
+		}
+		if c := s.GetIdFromURI("file:///vault/c.md"); c == a || c == b || c == shadowId {
+			t.Errorf("Id %d of new note is reused", c)
+		}
+	})
+}
+
+func TestAliasRemoved(t *testing.T) {
+	t.Run("1 Refs through alias go back to shadow", func(t *testing.T) {
+		s := newAliasTestStore()
+		a := s.GetIdFromURI("file:///vault/a.md")
+		s.addAlias(a, "My Alias")
+		b := s.GetIdFromURI("file:///vault/b.md")
+		s.AddUpdateDoc(b, NewDocumentData("# B\nsee [[My Alias#Part|x]] and [[a]]\n", nil))
+		aliasLoc := IdLocation{Id: b, Range: lsp.Range{
+			Start: lsp.Position{Line: 1, Character: 4},
+			End:   lsp.Position{Line: 1, Character: 23},
+		}}
+		targetLoc := IdLocation{Id: b, Range: lsp.Range{
+			Start: lsp.Position{Line: 1, Character: 28},
+			End:   lsp.Position{Line: 1, Character: 33},
+		}}
+		s.LinkStore.AddRef(a, "#Part", aliasLoc)
+		s.LinkStore.AddRef(a, "", targetLoc)
+
+		s.removeAlias(a, "My Alias")
+
+		ids := s.TargetStore["My Alias"]
+		shadowId, isShadow := s.IdStore.findShadowId(ids)
+		if !isShadow {
+			t.Fatalf("TargetStore >>> shadow id got %v", ids)
+		}
+		if refs, _ := s.LinkStore.GetRefs(shadowId, "#Part"); !slices.Equal(refs, []IdLocation{aliasLoc}) {
+			t.Errorf("Refs of shadow >>> %v got %v", aliasLoc, refs)
+		}
+		if refs, _ := s.LinkStore.GetRefs(a, "#Part"); len(refs) != 0 {
+			t.Errorf("Refs of a through alias >>> [] got %v", refs)
+		}
+		if refs, _ := s.LinkStore.GetRefs(a, ""); !slices.Equal(refs, []IdLocation{targetLoc}) {
+			t.Errorf("Refs of a >>> %v got %v", targetLoc, refs)
+		}
+
+		// unloading b finds them on shadow
+		for _, id := range s.getIds("My Alias") {
+			s.LinkStore.RemoveRef(id, "#Part", aliasLoc)
+		}
+		if refs, _ := s.LinkStore.GetRefs(shadowId, "#Part"); len(refs) != 0 {
+			t.Errorf("Refs of shadow after unload >>> [] got %v", refs)
+		}
+	})
+}
@@ -0,0 +1,297 @@
+package data
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sylmark/lsp"
+	"time"
+)
+
+func (s *Store) addFrontMatterValueLocation(key string, value string, loc lsp.Location) {
+	values, found := s.FrontMatterValues[key]
+	if !found {
+		values = map[string][]lsp.Location{}
+		s.FrontMatterValues[key] = values
+	}
+	addLocation(values, value, loc)
+}
+
+func (s *Store) removeFrontMatterValueLocation(key string, value string, loc lsp.Location) {
+	values, found := s.FrontMatterValues[key]
+	if !found {
+		return
+	}
+	removeLocation(values, value, loc)
+	if len(values) == 0 {
+		delete(s.FrontMatterValues, key)
+	}
+}
+
+type frontMatterCount struct {
+	text  string
+	count int
+}
+
+// most used first, values declared in schema are included, uses on line of uri are not counted
+func (s *Store) getFrontMatterValueCounts(key string, uri lsp.DocumentURI, line int) (counts []frontMatterCount) {
+	seen := map[string]int{}
+	for value, locs := range s.FrontMatterValues[key] {
+		for _, loc := range locs {
+			if loc.URI != uri || loc.Range.Start.Line != line {
+				seen[value]++
+			}
+		}
+	}
+	for _, value := range s.Config.FrontMatter[key].Values {
+		if _, found := seen[value]; !found {
+			seen[value] = 0
+		}
+	}
+	for value, count := range seen {
+		if count == 0 && !slices.Contains(s.Config.FrontMatter[key].Values, value) {
+			continue
+		}
+		counts = append(counts, frontMatterCount{text: value, count: count})
+	}
+	slices.SortFunc(counts, func(a, b frontMatterCount) int {
+		return cmp.Or(b.count-a.count, strings.Compare(a.text, b.text))
+	})
+	return counts
+}
+
+// notes using the key, a key is written once per note
+func (s *Store) getFrontMatterKeyCounts() (counts []frontMatterCount) {
+	seen := map[string]int{}
+	for key, locs := range s.FrontMatterKeys {
+		seen[key] = len(locs)
+	}
+	for key := range s.Config.FrontMatter {
+		if _, found := seen[key]; !found {
+			seen[key] = 0
+		}
+	}
+	for key, count := range seen {
+		counts = append(counts, frontMatterCount{text: key, count: count})
+	}
+	slices.SortFunc(counts, func(a, b frontMatterCount) int {
+		return cmp.Or(b.count-a.count, strings.Compare(a.text, b.text))
+	})
+	return counts
+}
+
+// key of line within front matter, "- item" lines and lines of multiline arrays belong to key above them
+func getFrontMatterKeyAt(fm FrontMatter, lineNumber int) (key string, ok bool) {
+	for _, field := range fm.Fields {
+		if field.KeyRange.Start.Line > lineNumber {
+			break
+		}
+		key, ok = field.Key, true
+	}
+	return key, ok
+}
+
+// where the value being typed starts, after separator, [ or , and an opening quote
+func getFrontMatterValueStart(line string, after int) int {
+	start := after
+	if i := strings.LastIndexAny(line, "[,"); i >= start {
+		start = i + 1
+	}
+	for start < len(line) && (line[start] == ' ' || line[start] == '\t' || line[start] == '"' || line[start] == '\'') {
+		start++
+	}
+	return start
+}
+
+// keys used across vault at start of a line, values seen for the key after it
+func (s *Store) GetFrontMatterCompletions(uri lsp.DocumentURI, fm FrontMatter, content Document, pos lsp.Position) []lsp.CompletionItem {
+	completions := []lsp.CompletionItem{}
+	line := content.GetLine(pos.Line)
+	if pos.Character > len(line) {
+		return completions
+	}
+	typed := line[:pos.Character]
+	separator := ":"
+	if fm.Format == FrontMatterTOML {
+		separator = "="
+	}
+
+	var key string
+	var start int
+	isValue := true
+	trimmed := strings.TrimLeft(typed, " \t")
+	if sepI := strings.Index(typed, separator); sepI != -1 && len(trimmed) == len(typed) {
+		key = strings.Trim(strings.TrimSpace(typed[:sepI]), `"'`)
+		start = getFrontMatterValueStart(typed, sepI+1)
+	} else if len(trimmed) != len(typed) || strings.HasPrefix(trimmed, "-") {
+		// item of list of key above
+		k, ok := getFrontMatterKeyAt(fm, pos.Line)
+		if !ok {
+			return completions
+		}
+		key = k
+		after := len(typed) - len(trimmed)
+		if strings.HasPrefix(trimmed, "-") {
+			after++
+		}
+		start = getFrontMatterValueStart(typed, after)
+	} else {
+		isValue = false
+	}
+
+	rng := lsp.Range{
+		Start: lsp.Position{Line: pos.Line, Character: start},
+		End:   pos,
+	}
+	if !isValue {
+		for i, kc := range s.getFrontMatterKeyCounts() {
+			completions = append(completions, lsp.CompletionItem{
+				Label:    kc.text,
+				Kind:     lsp.PropertyCompletion,
+				SortText: fmt.Sprintf("%05d", i),
+				TextEdit: &lsp.TextEdit{
+					Range:   rng,
+					NewText: kc.text + getFrontMatterSeparator(fm.Format),
+				},
+				Documentation: fmt.Sprintf("Used in %d notes", kc.count),
+			})
+		}
+		return completions
+	}
+
+	for i, vc := range s.getFrontMatterValueCounts(key, uri, pos.Line) {
+		completions = append(completions, lsp.CompletionItem{
+			Label:    vc.text,
+			Kind:     lsp.ValueCompletion,
+			SortText: fmt.Sprintf("%05d", i),
+			TextEdit: &lsp.TextEdit{
+				Range:   rng,
+				NewText: vc.text,
+			},
+			Detail:        key,
+			Documentation: fmt.Sprintf("Used %d times", vc.count),
+		})
+	}
+	return completions
+}
+
+func getFrontMatterSeparator(format FrontMatterFormat) string {
+	if format == FrontMatterTOML {
+		return " = "
+	}
+	return ": "
+}
+
+// usage of key under position across vault
+func (s *Store) GetFrontMatterHover(id Id, pos lsp.Position) (content string, rng lsp.Range, ok bool) {
+	doc, found := s.GetDoc(id)
+	if !found {
+		return "", rng, false
+	}
+	fm, found := ParseFrontMatter(string(doc.Content))
+	if !found || !fm.ContainsLine(pos.Line) {
+		return "", rng, false
+	}
+	i := slices.IndexFunc(fm.Fields, func(field FrontMatterField) bool {
+		return field.KeyRange.Start.Line == pos.Line &&
+			field.KeyRange.Start.Character <= pos.Character && pos.Character <= field.KeyRange.End.Character
+	})
+	if i == -1 {
+		return "", rng, false
+	}
+	key := fm.Fields[i].Key
+
+	content = fmt.Sprintf("`%s` used in %d notes\n", key, len(s.FrontMatterKeys[key]))
+	schema := s.Config.FrontMatter[key]
+	if len(schema.Type) > 0 {
+		content += fmt.Sprintf("\nType: `%s`\n", schema.Type)
+	}
+	if schema.Required {
+		content += "\nRequired\n"
+	}
+	counts := s.getFrontMatterValueCounts(key, "", -1)
+	if len(counts) > 0 {
+		content += "\n---\n"
+	}
+	for n, vc := range counts {
+		if n == 10 {
+			content += fmt.Sprintf("\n- ... %d more", len(counts)-n)
+			break
+		}
+		content += fmt.Sprintf("\n- `%s` %d", vc.text, vc.count)
+	}
+	return content, fm.Fields[i].KeyRange, true
+}
+
+func (s *Store) checkFrontMatterValue(schema FrontMatterKeySchema, value string) (msg string, ok bool) {
+	switch schema.Type {
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Sprintf("`%s` is not a number", value), false
+		}
+	case "bool":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Sprintf("`%s` is not true or false", value), false
+		}
+	case "date":
+		_, err := time.Parse(s.Config.DateLayout, value)
+		if err != nil {
+			if _, err = time.Parse(time.RFC3339, value); err != nil {
+				return fmt.Sprintf("`%s` is not a date like %s", value, s.Config.DateLayout), false
+			}
+		}
+	}
+	if len(schema.Values) > 0 && !slices.Contains(schema.Values, value) {
+		return fmt.Sprintf("`%s` is not one of %s", value, strings.Join(schema.Values, ", ")), false
+	}
+	return "", true
+}
+
+// fields not matching front_matter schema of config, notes without front matter are left alone
+func (s *Store) getFrontMatterDiagnostics(content string) (items []lsp.Diagnostic) {
+	if len(s.Config.FrontMatter) == 0 {
+		return
+	}
+	fm, ok := ParseFrontMatter(content)
+	if !ok {
+		return
+	}
+	add := func(rng lsp.Range, msg string) {
+		items = append(items, lsp.Diagnostic{
+			Range:    &rng,
+			Severity: lsp.DiagnosticSeverityWarning,
+			Message:  msg,
+		})
+	}
+
+	for key, schema := range s.Config.FrontMatter {
+		if _, found := fm.GetField(key); !found && schema.Required {
+			add(lsp.Range{End: lsp.Position{Line: 0, Character: 3}}, fmt.Sprintf("Front matter `%s` is required", key))
+		}
+	}
+	for _, field := range fm.Fields {
+		schema, found := s.Config.FrontMatter[field.Key]
+		if !found {
+			continue
+		}
+		if schema.Type == "list" && !field.IsList && len(field.Values) > 0 {
+			add(field.Values[0].Range, fmt.Sprintf("`%s` should be a list", field.Key))
+			continue
+		}
+		if schema.Type != "list" && len(schema.Type) > 0 && field.IsList {
+			add(field.KeyRange, fmt.Sprintf("`%s` should be a single %s", field.Key, schema.Type))
+			continue
+		}
+		for _, value := range field.Values {
+			if msg, ok := s.checkFrontMatterValue(schema, value.Text); !ok {
+				add(value.Range, msg)
+			}
+		}
+	}
+	slices.SortFunc(items, func(a, b lsp.Diagnostic) int {
+		return cmp.Or(a.Range.Start.Line-b.Range.Start.Line, a.Range.Start.Character-b.Range.Start.Character)
+	})
+	return items
+}
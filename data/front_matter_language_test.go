@@ -0,0 +1,163 @@
+package data
+
+import (
+	"slices"
+	"strings"
+	"sylmark/lsp"
+	"testing"
+)
+
+const frontMatterTestURI lsp.DocumentURI = "file:///vault/a.md"
+
+// status used in two other notes and tags in one
+func newFrontMatterTestStore() *Store {
+	s := NewStore()
+	s.Config.RootPath = "/vault"
+	line := lsp.Range{Start: lsp.Position{Line: 1}, End: lsp.Position{Line: 1, Character: 10}}
+	other := lsp.Location{URI: "file:///vault/b.md", Range: line}
+	another := lsp.Location{URI: "file:///vault/c.md", Range: line}
+	addLocation(s.FrontMatterKeys, "status", other)
+	addLocation(s.FrontMatterKeys, "status", another)
+	addLocation(s.FrontMatterKeys, "tags", other)
+	s.addFrontMatterValueLocation("status", "done", other)
+	s.addFrontMatterValueLocation("status", "done", another)
+	s.addFrontMatterValueLocation("tags", "work", other)
+	// being typed on line 1 of note itself
+	s.addFrontMatterValueLocation("status", "dra", lsp.Location{URI: frontMatterTestURI, Range: line})
+	s.Config.FrontMatter = map[string]FrontMatterKeySchema{
+		"status": {Values: []string{"done", "draft"}},
+	}
+	return &s
+}
+
+func TestFrontMatterValueCounts(t *testing.T) {
+	s := newFrontMatterTestStore()
+	tests := []struct {
+		name string
+		key  string
+		uri  lsp.DocumentURI
+		line int
+		want []frontMatterCount
+	}{
+		{"1 Most used first with schema values", "status", frontMatterTestURI, 1, []frontMatterCount{{"done", 2}, {"draft", 0}}},
+		{"2 Uses on other lines are counted", "status", frontMatterTestURI, 2, []frontMatterCount{{"done", 2}, {"dra", 1}, {"draft", 0}}},
+		{"3 Key without schema", "tags", frontMatterTestURI, 1, []frontMatterCount{{"work", 1}}},
+		{"4 Unknown key", "title", frontMatterTestURI, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.getFrontMatterValueCounts(tt.key, tt.uri, tt.line); !slices.Equal(got, tt.want) {
+				t.Errorf("Counts >>> %v got %v", tt.want, got)
+			}
+		})
+	}
+	t.Run("5 Keys by notes using them", func(t *testing.T) {
+		want := []frontMatterCount{{"status", 2}, {"tags", 1}}
+		if got := s.getFrontMatterKeyCounts(); !slices.Equal(got, want) {
+			t.Errorf("Counts >>> %v got %v", want, got)
+		}
+	})
+}
+
+func TestFrontMatterCompletions(t *testing.T) {
+	s := newFrontMatterTestStore()
+	tests := []struct {
+		name    string
+		content string
+		pos     lsp.Position
+		// empty for key completions
+		key    string
+		start  int
+		labels []string
+	}{
+		{"1 YAML key", "---\nsta\n---\n", lsp.Position{Line: 1, Character: 3}, "", 0, []string{"status", "tags"}},
+		{"2 YAML value", "---\nstatus: dra\n---\n", lsp.Position{Line: 1, Character: 11}, "status", 8, []string{"done", "draft"}},
+		{"3 YAML list item", "---\ntags:\n  - wo\n---\n", lsp.Position{Line: 2, Character: 6}, "tags", 4, []string{"work"}},
+		{"4 YAML inline list", "---\ntags: [home, wo\n---\n", lsp.Position{Line: 1, Character: 15}, "tags", 13, []string{"work"}},
+		{"5 TOML value", "+++\nstatus = \"dr\n+++\n", lsp.Position{Line: 1, Character: 12}, "status", 10, []string{"done", "draft"}},
+		{"6 TOML multiline array", "+++\ntags = [\n  \"one\",\n  \"wo\n+++\n", lsp.Position{Line: 3, Character: 5}, "tags", 3, []string{"work"}},
+		{"7 TOML key", "+++\nta\n+++\n", lsp.Position{Line: 1, Character: 2}, "", 0, []string{"status", "tags"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm, ok := ParseFrontMatter(tt.content)
+			if !ok {
+				t.Fatalf("Front matter not found")
+			}
+			completions := s.GetFrontMatterCompletions(frontMatterTestURI, fm, Document(tt.content), tt.pos)
+			var labels []string
+			for _, c := range completions {
+				labels = append(labels, c.Label)
+				if c.TextEdit.Range.Start.Character != tt.start {
+					t.Errorf("%s start >>> %d got %d", c.Label, tt.start, c.TextEdit.Range.Start.Character)
+				}
+				if len(tt.key) == 0 && c.Kind != lsp.PropertyCompletion {
+					t.Errorf("%s kind >>> property got %v", c.Label, c.Kind)
+				}
+				if len(tt.key) > 0 && (c.Kind != lsp.ValueCompletion || c.Detail != tt.key) {
+					t.Errorf("%s >>> value of %s got %v %s", c.Label, tt.key, c.Kind, c.Detail)
+				}
+			}
+			if !slices.Equal(labels, tt.labels) {
+				t.Errorf("Labels >>> %v got %v", tt.labels, labels)
+			}
+		})
+	}
+	t.Run("8 Key gets separator of format", func(t *testing.T) {
+		for content, want := range map[string]string{"---\nta\n---\n": "tags: ", "+++\nta\n+++\n": "tags = "} {
+			fm, _ := ParseFrontMatter(content)
+			completions := s.GetFrontMatterCompletions(frontMatterTestURI, fm, Document(content), lsp.Position{Line: 1, Character: 2})
+			i := slices.IndexFunc(completions, func(c lsp.CompletionItem) bool { return c.Label == "tags" })
+			if i == -1 || completions[i].TextEdit.NewText != want {
+				t.Errorf("New text >>> [%s] got %v", want, completions)
+			}
+		}
+	})
+}
+
+func TestFrontMatterDiagnostics(t *testing.T) {
+	s := NewStore()
+	s.Config.FrontMatter = map[string]FrontMatterKeySchema{
+		"id":     {Required: true},
+		"status": {Type: "string", Values: []string{"done", "draft"}},
+		"count":  {Type: "number"},
+		"done":   {Type: "bool"},
+		"due":    {Type: "date"},
+		"tags":   {Type: "list"},
+	}
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"1 No front matter", "# Note\n", nil},
+		{"2 Valid fields", "---\nid: 1\nstatus: done\ncount: 3.5\ndone: true\ndue: 2024-01-02\ntags: [a, b]\nother: x\n---\n", nil},
+		{"3 Values not of type", "---\nid: 1\ncount: many\ndone: maybe\ndue: tomorrow\n---\n", []string{
+			"`many` is not a number",
+			"`maybe` is not true or false",
+			"`tomorrow` is not a date like 2006-01-02",
+		}},
+		{"4 Value not declared", "---\nid: 1\nstatus: open\n---\n", []string{"`open` is not one of done, draft"}},
+		{"5 List and single values", "---\nid: 1\nstatus: [done]\ntags: a\n---\n", []string{"`status` should be a single string", "`tags` should be a list"}},
+		{"6 Required missing", "---\nstatus: done\n---\n", []string{"Front matter `id` is required"}},
+		{"7 TOML", "+++\nid = 1\ncount = \"x\"\ntags = [\n  \"a\",\n]\n+++\n", []string{"`x` is not a number"}},
+		{"8 Date as RFC3339", "---\nid: 1\ndue: 2024-01-02T10:00:00Z\n---\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, item := range s.getFrontMatterDiagnostics(tt.content) {
+				got = append(got, item.Message)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Messages >>> [%s] got [%s]", strings.Join(tt.want, "; "), strings.Join(got, "; "))
+			}
+		})
+	}
+	t.Run("9 Nothing without schema", func(t *testing.T) {
+		s := NewStore()
+		if items := s.getFrontMatterDiagnostics("---\ncount: many\n---\n"); len(items) != 0 {
+			t.Errorf("Diagnostics >>> [] got %v", items)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package data
+
+import (
+	"slices"
+	"sylmark/lsp"
+	"testing"
+)
+
+func getFrontMatterTexts(values []FrontMatterValue) (texts []string) {
+	for _, v := range values {
+		texts = append(texts, v.Text)
+	}
+	return texts
+}
+
+func TestParseFrontMatter(t *testing.T) {
+	t.Run("1 No front matter", func(t *testing.T) {
+		if _, ok := ParseFrontMatter("# Note\n---\n"); ok {
+			t.Errorf("front matter must start at first line")
+		}
+		if _, ok := ParseFrontMatter("---\ntitle: Never closed\n"); ok {
+			t.Errorf("front matter must be closed")
+		}
+	})
+
+	t.Run("2 YAML scalars and lists", func(t *testing.T) {
+		content := "---\ntitle: \"My Note\" \naliases: [First, 'Second one']\ntags:\n  - project/alpha\n  - daily # a comment\nempty:\n---\n# Heading\n"
+		fm, ok := ParseFrontMatter(content)
+		if !ok || fm.Format != FrontMatterYAML {
+			t.Fatalf("yaml front matter not found")
+		}
+		if fm.Range.End.Line != 7 {
+			t.Errorf("End line >>> 7 got %d", fm.Range.End.Line)
+		}
+		if got := getFrontMatterTexts(fm.GetValues("title")); !slices.Equal(got, []string{"My Note"}) {
+			t.Errorf("title >>> [My Note] got %v", got)
+		}
+		if got := getFrontMatterTexts(fm.GetValues("aliases")); !slices.Equal(got, []string{"First", "Second one"}) {
+			t.Errorf("aliases >>> [First Second one] got %v", got)
+		}
+		if got := getFrontMatterTexts(fm.GetValues("tags")); !slices.Equal(got, []string{"project/alpha", "daily"}) {
+			t.Errorf("tags >>> [project/alpha daily] got %v", got)
+		}
+		field, ok := fm.GetField("empty")
+		if !ok || len(field.Values) != 0 {
+			t.Errorf("empty field >>> no values got %v", field)
+		}
+		want := lsp.Range{Start: lsp.Position{Line: 2, Character: 18}, End: lsp.Position{Line: 2, Character: 28}}
+		if got := fm.GetValues("aliases")[1].Range; got != want {
+			t.Errorf("quoted alias range >>> %v got %v", want, got)
+		}
+	})
+
+	t.Run("3 TOML with multiline array", func(t *testing.T) {
+		content := "+++\ntitle = 'Toml Note'\ntags = [\n  \"one\", \"two\", # comment\n  \"three\",\n]\n[params]\naliases = [\"ignored\"]\n+++\n"
+		fm, ok := ParseFrontMatter(content)
+		if !ok || fm.Format != FrontMatterTOML {
+			t.Fatalf("toml front matter not found")
+		}
+		if got := getFrontMatterTexts(fm.GetValues("title")); !slices.Equal(got, []string{"Toml Note"}) {
+			t.Errorf("title >>> [Toml Note] got %v", got)
+		}
+		if got := getFrontMatterTexts(fm.GetValues("tags")); !slices.Equal(got, []string{"one", "two", "three"}) {
+			t.Errorf("tags >>> [one two three] got %v", got)
+		}
+		if _, ok := fm.GetField("aliases"); ok {
+			t.Errorf("fields of tables are not top level")
+		}
+	})
+}
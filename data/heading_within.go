@@ -85,12 +85,15 @@ func (s *Store) GetLoadedDataStore(id Id, parse lsp.ParseFunction) *HeadingsStor
 				}
 			}
 		})
+		for _, b := range getBlockIds(string(docData.Content), docData.Trees, allLines) {
+			store.SetDef(string(b.subTarget), b.rng)
+		}
 		lsp.TraverseNodeWith(docData.Trees.GetInlineTree().RootNode(), func(n *tree_sitter.Node) {
 			switch n.Kind() {
 			case "wiki_link":
 				{
 					target, subTarget, isSubTarget, ok := GetWikilinkTargets(n, string(docData.Content))
-					if ok {
+					if ok && !isEmbedNode(n, string(docData.Content)) {
 						isSubheading := len(target) == 0 && isSubTarget
 						if isSubheading {
 							store.AddRef(string(subTarget), lsp.GetRange(n))
@@ -99,6 +102,11 @@ func (s *Store) GetLoadedDataStore(id Id, parse lsp.ParseFunction) *HeadingsStor
 				}
 			}
 		})
+		for _, e := range getEmbeds(string(docData.Content), docData.Trees, allLines) {
+			if len(e.target) == 0 && len(e.subTarget) > 0 {
+				store.AddRef(string(e.subTarget), e.rng)
+			}
+		}
 	}
 	return &store
 }
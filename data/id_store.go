@@ -15,6 +15,8 @@ type IdStore struct {
 	Id            map[Id]lsp.DocumentURI
 	uri           map[lsp.DocumentURI]Id
 	ShadowTargets map[Id][]Target
+	// last id given, ids of removed shadows are not reused
+	lastId Id
 
 	// hugoLinks string=hugoLink Id=fileLinkWhereLinkIs Id=targetLink
 	hugoLinks map[string]map[Id]Id
@@ -51,6 +53,9 @@ func (s *IdStore) Print() {
 }
 func (s *IdStore) ReplaceUri(id Id, uri lsp.DocumentURI) {
 	// utils.Sprintf("ReplaceUri       id=[%d] uri=[%s]", id, uri)
+	if oldUri, ok := s.Id[id]; ok && s.uri[oldUri] == id {
+		delete(s.uri, oldUri)
+	}
 	s.Id[id] = uri
 	s.uri[uri] = id
 
@@ -108,7 +113,8 @@ func (s *Store) isClaimableShadow(shadowId Id, uri lsp.DocumentURI) bool {
 
 // doesn't check if exists same uri, only store
 func (s *IdStore) addEntry(uri lsp.DocumentURI) Id {
-	id := Id(len(s.Id) + 1)
+	s.lastId++
+	id := s.lastId
 	// utils.Sprintf(" IdStore addEntry uri=[%s] id=[%d]", uri, id)
 	// utils.Sprintf("addEntry uri=[%s] id=[%d] ", uri, id)
 	s.Id[id] = uri
@@ -120,6 +126,12 @@ func (s *IdStore) addEntry(uri lsp.DocumentURI) Id {
 	return id
 }
 
+// shadow id whose targets are all claimed is not needed anymore
+func (s *IdStore) removeShadow(id Id) {
+	delete(s.ShadowTargets, id)
+	delete(s.Id, id)
+}
+
 // id with no URI
 func (s *IdStore) isShadowId(id Id) bool {
 	_, ok := s.ShadowTargets[id]
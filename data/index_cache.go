@@ -0,0 +1,121 @@
+package data
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sylmark/lsp"
+)
+
+// kept at root of vault, skipped while walking the vault
+const IndexCacheFileName = ".sylmark.cache"
+
+// bump when docEntry or the way entries are extracted changes
+const indexCacheVersion = 7
+
+// entries of a file as they were when it had this mtime and size
+type CachedDoc struct {
+	ModTime int64
+	Size    int64
+	Entries []docEntry
+}
+
+func (d CachedDoc) IsFresh(info fs.FileInfo) bool {
+	return d.ModTime == info.ModTime().UnixNano() && d.Size == info.Size()
+}
+
+// entries of every markdown file by path, so that unchanged files skip parsing on startup
+type IndexCache struct {
+	Version int
+	// entries depend on config, like md_link_web_mode
+	Config string
+	Docs   map[string]CachedDoc
+}
+
+func (s *Store) NewIndexCache() IndexCache {
+	return IndexCache{
+		Version: indexCacheVersion,
+		Config:  fmt.Sprintf("%+v", s.Config),
+		Docs:    map[string]CachedDoc{},
+	}
+}
+
+func (s *Store) getIndexCachePath() string {
+	return filepath.Join(s.Config.RootPath, IndexCacheFileName)
+}
+
+// empty cache when there is none or it was written by another version or config
+func (s *Store) ReadIndexCache() IndexCache {
+	cache := s.NewIndexCache()
+	file, err := os.Open(s.getIndexCachePath())
+	if err != nil {
+		return cache
+	}
+	defer file.Close()
+
+	var stored IndexCache
+	if err := gob.NewDecoder(file).Decode(&stored); err != nil {
+		return cache
+	}
+	if stored.Version != cache.Version || stored.Config != cache.Config || stored.Docs == nil {
+		return cache
+	}
+	return stored
+}
+
+// written to a temp file first so that a crash never leaves half a cache
+func (s *Store) WriteIndexCache(cache IndexCache) error {
+	path := s.getIndexCachePath()
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create index cache: %w", err)
+	}
+	if err := gob.NewEncoder(file).Encode(cache); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write index cache: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write index cache: %w", err)
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// same as LoadData, returns what has to be cached for the file
+func (s *Store) LoadDataForCache(id Id, content string, trees *lsp.Trees, info fs.FileInfo) CachedDoc {
+	entries := s.getDocEntries(id, content, trees)
+	s.loadEntries(id, entries)
+
+	cached := make([]docEntry, len(entries))
+	for i, e := range entries {
+		// ids are only valid for this session
+		e.LinkId = 0
+		cached[i] = e
+	}
+	return CachedDoc{
+		ModTime: info.ModTime().UnixNano(),
+		Size:    info.Size(),
+		Entries: cached,
+	}
+}
+
+// loads entries of an unchanged file without parsing it, inline links are resolved again
+func (s *Store) LoadCachedData(id Id, doc CachedDoc) {
+	entries := make([]docEntry, 0, len(doc.Entries))
+	for _, e := range doc.Entries {
+		if e.Kind == "inline_link" {
+			_, linkId, subTarget, found := s.addInlineTargetAndSubTarget(e.Url, id)
+			if !found {
+				continue
+			}
+			e.LinkId = linkId
+			e.SubTarget = subTarget
+		}
+		entries = append(entries, e)
+	}
+	s.loadEntries(id, entries)
+}
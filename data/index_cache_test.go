@@ -0,0 +1,125 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var cachedNotes = []struct{ name, content string }{
+	{"a.md", "# A\n- [ ] task\n\nsee [part](b.md#Part) and [c](dir/c.md)\n"},
+	{"b.md", "# B\n\n## Part\ntext ^blk\n"},
+	{"dir/c.md", "---\naliases: [See]\n---\n# C\n"},
+}
+
+// writes notes and loads them the way vault loading does when there is no cache
+func writeIndexCache(t *testing.T, s *Store) IndexCache {
+	parse := newTestParse(t)
+	cache := s.NewIndexCache()
+	for _, note := range cachedNotes {
+		path := filepath.Join(s.Config.RootPath, note.name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(note.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		trees := parse(note.content, nil)
+		cache.Docs[path] = s.LoadDataForCache(s.GetIdFromURI(testNoteURI(s, note.name)), note.content, trees, info)
+		trees.Close()
+	}
+	if err := s.WriteIndexCache(cache); err != nil {
+		t.Fatal(err)
+	}
+	return cache
+}
+
+func TestIndexCache(t *testing.T) {
+	written, _ := newTestVault(t)
+	writeIndexCache(t, written)
+
+	t.Run("1 Cached entries load same as parsing", func(t *testing.T) {
+		cached := NewStore()
+		cached.Config.RootPath = written.Config.RootPath
+		cache := cached.ReadIndexCache()
+		if len(cache.Docs) != len(cachedNotes) {
+			t.Fatalf("Cached docs >>> %d got %d", len(cachedNotes), len(cache.Docs))
+		}
+		for _, note := range cachedNotes {
+			path := filepath.Join(cached.Config.RootPath, note.name)
+			info, _ := os.Stat(path)
+			if !cache.Docs[path].IsFresh(info) {
+				t.Errorf("%s should be fresh", note.name)
+			}
+			cached.LoadCachedData(cached.GetIdFromURI(testNoteURI(&cached, note.name)), cache.Docs[path])
+		}
+
+		parsed := NewStore()
+		parsed.Config.RootPath = written.Config.RootPath
+		parse := newTestParse(t)
+		for _, note := range cachedNotes {
+			trees := parse(note.content, nil)
+			parsed.LoadData(parsed.GetIdFromURI(testNoteURI(&parsed, note.name)), note.content, trees)
+			trees.Close()
+		}
+
+		if !reflect.DeepEqual(parsed.LinkStore, cached.LinkStore) {
+			t.Errorf("LinkStore >>> %v got %v", parsed.LinkStore, cached.LinkStore)
+		}
+		if !reflect.DeepEqual(parsed.TargetStore, cached.TargetStore) {
+			t.Errorf("TargetStore >>> %v got %v", parsed.TargetStore, cached.TargetStore)
+		}
+		if !reflect.DeepEqual(parsed.Tasks, cached.Tasks) {
+			t.Errorf("Tasks >>> %v got %v", parsed.Tasks, cached.Tasks)
+		}
+		if !reflect.DeepEqual(parsed.docEntries, cached.docEntries) {
+			t.Errorf("Entries >>> %v got %v", parsed.docEntries, cached.docEntries)
+		}
+	})
+	t.Run("2 Other version or config gives empty cache", func(t *testing.T) {
+		s := NewStore()
+		s.Config.RootPath = written.Config.RootPath
+		cache := s.ReadIndexCache()
+		cache.Version--
+		if err := s.WriteIndexCache(cache); err != nil {
+			t.Fatal(err)
+		}
+		if docs := s.ReadIndexCache().Docs; len(docs) != 0 {
+			t.Errorf("Docs of old version >>> [] got %d", len(docs))
+		}
+
+		writeIndexCache(t, &s)
+		s.Config.MdLinkWebMode = !s.Config.MdLinkWebMode
+		if docs := s.ReadIndexCache().Docs; len(docs) != 0 {
+			t.Errorf("Docs of other config >>> [] got %d", len(docs))
+		}
+	})
+	t.Run("3 Inline links are resolved to ids of this session", func(t *testing.T) {
+		s := NewStore()
+		s.Config.RootPath = written.Config.RootPath
+		cache := s.ReadIndexCache()
+		// b gets another id than when cache was written
+		s.GetIdFromURI(testNoteURI(&s, "other.md"))
+		b := s.GetIdFromURI(testNoteURI(&s, "b.md"))
+		s.LoadCachedData(b, cache.Docs[filepath.Join(s.Config.RootPath, "b.md")])
+		a := s.GetIdFromURI(testNoteURI(&s, "a.md"))
+		s.LoadCachedData(a, cache.Docs[filepath.Join(s.Config.RootPath, "a.md")])
+
+		refs, _ := s.LinkStore.GetRefs(b, "#Part")
+		if len(refs) != 1 || refs[0].Id != a {
+			t.Errorf("Refs of b#Part >>> from %d got %v", a, refs)
+		}
+		c, found := s.FindIdFromURI(testNoteURI(&s, "dir/c.md"))
+		if !found {
+			t.Fatalf("Id of dir/c.md should be created for it's ref")
+		}
+		if refs, _ := s.LinkStore.GetRefs(c, ""); len(refs) != 1 || refs[0].Id != a {
+			t.Errorf("Refs of dir/c >>> from %d got %v", a, refs)
+		}
+	})
+}
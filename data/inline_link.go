@@ -18,7 +18,7 @@ func (s *Store) GetInlineLinkCompletions(arg string, text string, rng lsp.Range,
 	otherFilesOnly := mdFilesOnly && len(arg) > 2 && arg[1] == ' '
 	strppedArg := strings.TrimSpace(arg)
 	isHeadingMode := strings.ContainsRune(arg, '#')
-	fileId := s.GetIdFromURI(*uri)
+	fileId, _ := s.FindIdFromURI(*uri)
 	sourcePath, err := DirPathFromURI(*uri)
 	if err != nil {
 		slog.Error("Something went wrong for path relative " + err.Error())
@@ -46,7 +46,7 @@ func (s *Store) GetInlineLinkCompletions(arg string, text string, rng lsp.Range,
 					Range:   rng,
 					NewText: link,
 				},
-				Detail: "",
+				Data: s.getCompletionData(targetId, subTargetNRange.subTarget),
 			})
 		}
 	} else {
@@ -216,7 +216,20 @@ func (s *Store) GetInlineFullTargetAndSubTarget(n *tree_sitter.Node, content str
 	return s.GetInlineTargetAndSubTarget(fullUrl, fileId)
 }
 
+// read only, targetId is 0 for notes which are neither loaded nor linked to
 func (s *Store) GetInlineTargetAndSubTarget(fullUrl string, fileId Id) (url lsp.DocumentURI, targetId Id, subTarget SubTarget, found bool) {
+	return s.getInlineTargetAndSubTarget(fullUrl, fileId, func(uri lsp.DocumentURI) Id {
+		id, _ := s.FindIdFromURI(uri)
+		return id
+	})
+}
+
+// for loading links, ids are created for missing notes so that refs can be added to them
+func (s *Store) addInlineTargetAndSubTarget(fullUrl string, fileId Id) (url lsp.DocumentURI, targetId Id, subTarget SubTarget, found bool) {
+	return s.getInlineTargetAndSubTarget(fullUrl, fileId, s.GetIdFromURI)
+}
+
+func (s *Store) getInlineTargetAndSubTarget(fullUrl string, fileId Id, getId func(lsp.DocumentURI) Id) (url lsp.DocumentURI, targetId Id, subTarget SubTarget, found bool) {
 
 	found = strings.ContainsRune(fullUrl, '#')
 	var target string
@@ -254,7 +267,7 @@ func (s *Store) GetInlineTargetAndSubTarget(fullUrl string, fileId Id) (url lsp.
 	url, _ = UriFromPath(target)
 	if IsMdFile(target) {
 		found = true
-		targetId = s.GetIdFromURI(url)
+		targetId = getId(url)
 	}
 	return url, targetId, subTarget, found
 }
@@ -1,8 +1,10 @@
 package data
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"slices"
 	"sylmark/lsp"
 )
 
@@ -98,6 +100,21 @@ func (linkStore *LinkStore) RemoveRef(id Id, subTarget SubTarget, loc IdLocation
 	return true
 }
 
+// ref at loc is at moved now
+func (linkStore *LinkStore) moveRef(id Id, subTarget SubTarget, loc IdLocation, moved IdLocation) {
+	refs := (*linkStore)[id].Refs[subTarget]
+	if i := slices.Index(refs, loc); i != -1 {
+		refs[i] = moved
+	}
+}
+
+func (linkStore *LinkStore) moveDef(id Id, subTarget SubTarget, rng lsp.Range, moved lsp.Range) {
+	link, found := (*linkStore)[id]
+	if found && link.Def[subTarget] == rng {
+		link.Def[subTarget] = moved
+	}
+}
+
 func (linkStore *LinkStore) AddDef(id Id, subTarget SubTarget, rng lsp.Range) bool {
 	if linkStore == nil {
 		return false
@@ -219,13 +236,17 @@ func (linkStore *LinkStore) getSubTargetsAndRanges(id Id) (subTargets []SubTarge
 	return subTargets
 }
 
-func (store *Store) GetRefsFromTarget(target Target, subTarget SubTarget) (refs []IdLocation, refFound bool) {
+// refs of every note target resolves to, nothing once ctx is done
+func (store *Store) GetRefsFromTarget(ctx context.Context, target Target, subTarget SubTarget) (refs []IdLocation, refFound bool) {
 	if store == nil {
 		return
 	}
-	s := *store
-	ids := s.getIds(target)
+	s := store
+	ids := s.findIds(target)
 	for _, id := range ids {
+		if ctx.Err() != nil {
+			return nil, false
+		}
 		lrefs, found := s.LinkStore.GetRefs(id, subTarget)
 		if found {
 			refFound = true
@@ -236,12 +257,16 @@ func (store *Store) GetRefsFromTarget(target Target, subTarget SubTarget) (refs
 	return
 }
 
-func (store *Store) GetDefsFromTarget(target Target, subTarget SubTarget) (defs []IdLocation, defFound bool) {
+// defs of every note target resolves to, nothing once ctx is done
+func (store *Store) GetDefsFromTarget(ctx context.Context, target Target, subTarget SubTarget) (defs []IdLocation, defFound bool) {
 	if store == nil {
 		return
 	}
-	s := *store
+	s := store
 	ids, defFound := s.getValidIds(target)
+	if ctx.Err() != nil {
+		return nil, false
+	}
 	if len(subTarget) == 0 {
 		for _, id := range ids {
 			defs = append(defs, IdLocation{
@@ -251,6 +276,9 @@ func (store *Store) GetDefsFromTarget(target Target, subTarget SubTarget) (defs
 		}
 	} else {
 		for _, id := range ids {
+			if ctx.Err() != nil {
+				return nil, false
+			}
 			def, found := s.LinkStore.GetDef(id, subTarget)
 			if found {
 				defFound = true
@@ -25,6 +25,56 @@ func GetSubTarget(node *tree_sitter.Node, content string) (subTarget SubTarget,
 	return
 }
 
+// atx or setext heading node, returns heading text and it's range without the # marker or underline
+func GetHeadingContent(node *tree_sitter.Node, content string) (text string, rng lsp.Range, ok bool) {
+	var contentNode *tree_sitter.Node
+	switch node.Kind() {
+	case "atx_heading":
+		if node.NamedChildCount() != 2 {
+			return "", rng, false
+		}
+		contentNode = node.NamedChild(1)
+	case "setext_heading":
+		paragraph := node.ChildByFieldName("heading_content")
+		if paragraph == nil || paragraph.NamedChildCount() == 0 {
+			return "", rng, false
+		}
+		contentNode = paragraph.NamedChild(0)
+	default:
+		return "", rng, false
+	}
+	return lsp.GetNodeContent(*contentNode, content), lsp.GetRange(contentNode), true
+}
+
+// 1 for # or === underline, 0 if not a heading
+func GetHeadingLevel(node *tree_sitter.Node) int {
+	var marker *tree_sitter.Node
+	switch node.Kind() {
+	case "atx_heading":
+		marker = node.NamedChild(0)
+	case "setext_heading":
+		marker = node.NamedChild(node.NamedChildCount() - 1)
+	}
+	if marker == nil {
+		return 0
+	}
+	switch marker.Kind() {
+	case "atx_h1_marker", "setext_h1_underline":
+		return 1
+	case "atx_h2_marker", "setext_h2_underline":
+		return 2
+	case "atx_h3_marker":
+		return 3
+	case "atx_h4_marker":
+		return 4
+	case "atx_h5_marker":
+		return 5
+	case "atx_h6_marker":
+		return 6
+	}
+	return 0
+}
+
 // wiki-link node only, subTarget includes '#'
 func GetWikilinkTargets(node *tree_sitter.Node, content string) (target Target, subTarget SubTarget, isSubTarget bool, ok bool) {
 	linkDestNode := node.NamedChild(0)
@@ -47,6 +97,9 @@ func (s *Store) ReplaceTarget(id Id, oldTarget Target, target Target) {
 	if oldTarget == target {
 		return
 	}
+	// links to old name break and the ones waiting for new name resolve
+	s.markDefChanged(id, "")
+	s.markTargetChanged(target)
 	ts := s.TargetStore
 	ids := ts[oldTarget]
 	switch len(ids) {
@@ -111,7 +164,7 @@ func (s *Store) LocationFromIdLocation(loc IdLocation) (*lsp.Location, bool) {
 
 // matches Id case insensitve and returns id
 func (s *Store) findIdFromURIFold(uri lsp.DocumentURI) (id Id, found bool) {
-	id, found = s.findIdFromURI(uri)
+	id, found = s.FindIdFromURI(uri)
 	if found {
 		return
 	}
@@ -126,7 +179,8 @@ func (s *Store) findIdFromURIFold(uri lsp.DocumentURI) (id Id, found bool) {
 	return id, id != 0
 }
 
-func (s *Store) findIdFromURI(uri lsp.DocumentURI) (id Id, found bool) {
+// read only lookup, unlike GetIdFromURI it never creates ids
+func (s *Store) FindIdFromURI(uri lsp.DocumentURI) (id Id, found bool) {
 	id, found = s.IdStore.uri[uri]
 	return
 }
@@ -205,9 +259,9 @@ func (s *Store) GetIdFromURI(uri lsp.DocumentURI) Id {
 	}
 }
 
-// filtered real ids from getIds
+// filtered real ids from findIds
 func (s *Store) getValidIds(target Target) ([]Id, bool) {
-	ids := s.getIds(target)
+	ids := s.findIds(target)
 	var validIds []Id
 	for _, id := range ids {
 		if !s.IdStore.isShadowId(id) {
@@ -217,6 +271,30 @@ func (s *Store) getValidIds(target Target) ([]Id, bool) {
 	return validIds, len(validIds) > 0
 }
 
+// read only getIds, unknown variant target gets shadow id of it's up variants which getIds would give
+func (s *Store) findIds(target Target) []Id {
+	if ids, ok := s.TargetStore.fetchIds(target); ok {
+		return ids
+	}
+	if !strings.ContainsRune(string(target), '/') {
+		return nil
+	}
+	variants := []Target{}
+	if oneUpTarget, isDiff := GetOneUpTarget(target); isDiff {
+		variants = append(variants, oneUpTarget)
+		if plainTarget, isDiff := GetPlainTarget(target); isDiff {
+			variants = append(variants, plainTarget)
+		}
+	}
+	for _, variant := range variants {
+		ids, _ := s.TargetStore.fetchIds(variant)
+		if id, isShadow := s.IdStore.findShadowId(ids); isShadow {
+			return []Id{id}
+		}
+	}
+	return nil
+}
+
 // creates id if doesn't exists, adds variants in case of non plain
 func (s *Store) getIds(target Target) []Id {
 	ts := s.TargetStore
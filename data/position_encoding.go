@@ -0,0 +1,79 @@
+package data
+
+import (
+	"os"
+	"strings"
+	"sylmark/lsp"
+)
+
+// converts between byte columns of trees and stores and characters of the negotiated encoding,
+// lines of documents are kept for the lifetime of the converter so use one per request
+type PositionConverter struct {
+	store *Store
+	lines map[lsp.DocumentURI][]string
+}
+
+func (s *Store) NewPositionConverter() PositionConverter {
+	return PositionConverter{
+		store: s,
+		lines: map[lsp.DocumentURI][]string{},
+	}
+}
+
+// content loaded in DocStore or on disk for closed docs
+func (c PositionConverter) getLine(uri lsp.DocumentURI, line int) string {
+	lines, found := c.lines[uri]
+	if !found {
+		if id, ok := c.store.FindIdFromURI(uri); ok {
+			if docData, ok := c.store.DocStore[id]; ok {
+				lines = strings.Split(string(docData.Content), "\n")
+			}
+		}
+		if lines == nil {
+			if path, err := PathFromURI(uri); err == nil {
+				if content, err := os.ReadFile(path); err == nil {
+					lines = strings.Split(string(content), "\n")
+				}
+			}
+		}
+		c.lines[uri] = lines
+	}
+	if line < 0 || line >= len(lines) {
+		return ""
+	}
+	return lines[line]
+}
+
+func (c PositionConverter) isByteEncoding() bool {
+	return c.store.PositionEncoding == lsp.PositionEncodingUTF8
+}
+
+func (c PositionConverter) ToBytePosition(uri lsp.DocumentURI, pos lsp.Position) lsp.Position {
+	if c.isByteEncoding() {
+		return pos
+	}
+	pos.Character = lsp.ByteFromCharacter(c.getLine(uri, pos.Line), pos.Character, c.store.PositionEncoding)
+	return pos
+}
+
+func (c PositionConverter) ToByteRange(uri lsp.DocumentURI, rng lsp.Range) lsp.Range {
+	return lsp.Range{
+		Start: c.ToBytePosition(uri, rng.Start),
+		End:   c.ToBytePosition(uri, rng.End),
+	}
+}
+
+func (c PositionConverter) FromBytePosition(uri lsp.DocumentURI, pos lsp.Position) lsp.Position {
+	if c.isByteEncoding() {
+		return pos
+	}
+	pos.Character = lsp.CharacterFromByte(c.getLine(uri, pos.Line), pos.Character, c.store.PositionEncoding)
+	return pos
+}
+
+func (c PositionConverter) FromByteRange(uri lsp.DocumentURI, rng lsp.Range) lsp.Range {
+	return lsp.Range{
+		Start: c.FromBytePosition(uri, rng.Start),
+		End:   c.FromBytePosition(uri, rng.End),
+	}
+}
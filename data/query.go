@@ -1,58 +1,306 @@
 package data
 
 import (
+	"context"
+	"fmt"
+	"hash/fnv"
 	"log/slog"
+	"strings"
 	"sylmark/lsp"
+	"time"
 
 	tree_sitter "github.com/tree-sitter/go-tree-sitter"
 )
 
-func getSemanticToken(node *tree_sitter.Node, tokenTypeIndex uint, tokenModifierIndex uint) []uint {
+// indexes in SemanticTokenTypes, tags stay at 0 as they always were
+const (
+	tagTokenType uint = iota
+	fileLinkTokenType
+	headingLinkTokenType
+	footNoteTokenType
+	dateLinkTokenType
+)
+
+// bits of SemanticTokenModifiers
+const (
+	unresolvedTokenModifier uint = 1 << iota
+	declarationTokenModifier
+)
+
+var SemanticTokenTypes = []lsp.SemanticTokenType{
+	lsp.ClassSematicTokenType,
+	lsp.NamespaceSematicTokenType,
+	lsp.PropertySematicTokenType,
+	lsp.VariableSematicTokenType,
+	lsp.NumberSematicTokenType,
+}
+
+// unresolved is not in the spec, highlight with @lsp.mod.unresolved
+var SemanticTokenModifiers = []lsp.SemanticTokenModifier{
+	"unresolved",
+	lsp.DeclarationSemanticTokenModifier,
+}
+
+type semanticToken struct {
+	line      uint
+	char      uint
+	length    uint
+	tokenType uint
+	modifiers uint
+}
+
+type semanticTokensEntry struct {
+	resultId string
+	data     []uint
+}
 
-	return []uint{
-		node.StartPosition().Row,    //line
-		node.StartPosition().Column, //char
-		// node.EndPosition().Column - node.StartPosition().Column, //length
-		node.EndByte() - node.StartByte(), //length
-		tokenTypeIndex,
-		tokenModifierIndex,
+func getSemanticToken(node *tree_sitter.Node, tokenType uint, modifiers uint) (semanticToken, bool) {
+	start := node.StartPosition()
+	end := node.EndPosition()
+	// multiline tokens are not supported by every client
+	if start.Row != end.Row {
+		return semanticToken{}, false
 	}
+	return semanticToken{
+		line:      start.Row,
+		char:      start.Column,
+		length:    end.Column - start.Column,
+		tokenType: tokenType,
+		modifiers: modifiers,
+	}, true
 }
 
-func (s *Store) GetSemanticTokens(id Id, parse lsp.ParseFunction) lsp.SemantiTokens {
-	intTokens := []uint{}
+// tokens can't overlap, last one is the only one which can hold token
+func (t semanticToken) within(tokens []semanticToken) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	last := tokens[len(tokens)-1]
+	return last.line == t.line && t.char < last.char+last.length
+}
+
+// these token poistions are relative to last one
+func encodeSemanticTokens(tokens []semanticToken) []uint {
+	intTokens := make([]uint, 0, len(tokens)*5)
+	var lastLine uint
+	var lastStart uint
+	for _, token := range tokens {
+		char := token.char
+		if token.line == lastLine {
+			char -= lastStart
+		}
+		intTokens = append(intTokens, token.line-lastLine, char, token.length, token.tokenType, token.modifiers)
+		lastLine = token.line
+		lastStart = token.char
+	}
+	return intTokens
+}
 
-	// get tokens and convert them to intTokens
+func getSemanticTokensResultId(data []uint) string {
+	h := fnv.New64a()
+	for _, d := range data {
+		fmt.Fprintf(h, "%d,", d)
+	}
+	return fmt.Sprintf("%x", h.Sum64())
+}
+
+// daily notes like [[2025-01-31]] or monthly ones
+func (s *Store) isDateTarget(target Target) bool {
+	plain, ok := GetPlainTarget(target)
+	if !ok {
+		return false
+	}
+	for _, layout := range []string{s.Config.DateLayout, s.Config.MonthDateLayout} {
+		if _, err := time.Parse(layout, string(plain)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) getWikiLinkToken(ctx context.Context, id Id, n *tree_sitter.Node, content string) (semanticToken, bool) {
+	target, subTarget, _, ok := GetWikilinkTargets(n, content)
+	if !ok {
+		return semanticToken{}, false
+	}
+	tokenType := fileLinkTokenType
+	if len(subTarget) > 0 {
+		tokenType = headingLinkTokenType
+	}
+	if len(target) > 0 && s.isDateTarget(target) {
+		tokenType = dateLinkTokenType
+	}
+
+	var found bool
+	if len(target) == 0 {
+		_, found = s.LinkStore.GetDef(id, subTarget)
+	} else {
+		_, found = s.GetDefsFromTarget(ctx, target, subTarget)
+	}
+	var modifiers uint
+	if !found {
+		modifiers |= unresolvedTokenModifier
+	}
+	return getSemanticToken(n, tokenType, modifiers)
+}
+
+func (s *Store) getInlineLinkToken(id Id, uri lsp.DocumentURI, n *tree_sitter.Node, content string) (semanticToken, bool) {
+	destNode := getLinkDestinationNode(n)
+	if destNode == nil {
+		return semanticToken{}, false
+	}
+	dest := lsp.GetNodeContent(*destNode, content)
+	if len(dest) == 0 || isExternalLink(dest) {
+		return semanticToken{}, false
+	}
+	tokenType := fileLinkTokenType
+	if strings.ContainsRune(dest, '#') {
+		tokenType = headingLinkTokenType
+	}
+
+	var found bool
+	if dest[0] == '#' {
+		_, found = s.LinkStore.GetDef(id, SubTarget(dest))
+		if !found {
+			_, found = s.LinkStore.GetDef(id, SubTarget(s.DecodeForInlineLinkdownLinkPath(dest)))
+		}
+	} else {
+		_, found = s.checkInlineDestination(id, uri, diagnosticLink{Kind: n.Kind(), Dest: dest})
+	}
+	var modifiers uint
+	if !found {
+		modifiers |= unresolvedTokenModifier
+	}
+	return getSemanticToken(n, tokenType, modifiers)
+}
+
+func getFootNoteToken(n *tree_sitter.Node, doc Document, footNotes *FootNotesStore) (semanticToken, bool) {
+	linkTextNode := n.NamedChild(0)
+	if linkTextNode == nil {
+		return semanticToken{}, false
+	}
+	rng := lsp.GetRange(n)
+	line := doc.GetLine(rng.Start.Line)
+	var modifiers uint
+	if _, isDefinition := getExecertOfShortcutLink(rng.End.Character-1, line); isDefinition {
+		modifiers |= declarationTokenModifier
+	} else {
+		footNote, ok := footNotes.GetFootNote(lsp.GetNodeContent(*linkTextNode, string(doc)))
+		if !ok || footNote.Def == nil {
+			modifiers |= unresolvedTokenModifier
+		}
+	}
+	return getSemanticToken(n, footNoteTokenType, modifiers)
+}
+
+// tokens overlapping rng of byte columns, all of them when rng is nil, not ok once ctx is done
+func (s *Store) getSemanticTokens(ctx context.Context, id Id, rng *lsp.Range, parse lsp.ParseFunction) (tokens []semanticToken, ok bool) {
 	docData, found := s.GetDocMustTree(id, parse)
 	if !found {
 		slog.Error("Shocking doc not found for SemantiTokens")
-		return lsp.SemantiTokens{
-			Data: []uint{},
-		}
+		return nil, false
+	}
+	uri, _ := s.GetUri(id)
+	content := string(docData.Content)
+	footNotes := docData.FootNotes
+	if footNotes == nil {
+		footNotes = s.GetLoadedFootNotesStore(id, parse)
 	}
 
-	// these token poistions are relative to last one hence lastLastLine
-	var lastLine uint
-	var lastStart uint
 	lsp.TraverseNodeWith(docData.Trees.GetInlineTree().RootNode(), func(n *tree_sitter.Node) {
+		if ctx.Err() != nil || rng != nil && !rangesOverlap(lsp.GetRange(n), *rng) {
+			return
+		}
+		var token semanticToken
+		var ok bool
 		switch n.Kind() {
 		case "tag":
-			{
-				token := getSemanticToken(n, 0, 0)
-				lastLastLine := lastLine
-				lastLastStart := lastStart // column in case two are on same row columns relative needed
-				lastLine = token[0]
-				lastStart = token[1]
-				token[0] = token[0] - lastLastLine
-				if lastLastLine == lastLine {
-					token[1] = token[1] - lastLastStart
-				}
-				intTokens = append(intTokens, token...)
-			}
+			token, ok = getSemanticToken(n, tagTokenType, 0)
+		case "wiki_link":
+			token, ok = s.getWikiLinkToken(ctx, id, n, content)
+		case "inline_link", "image":
+			token, ok = s.getInlineLinkToken(id, uri, n, content)
+		case "shortcut_link":
+			token, ok = getFootNoteToken(n, docData.Content, footNotes)
+		}
+		// parents come first, a tag within link text is part of the link
+		if ok && !token.within(tokens) {
+			tokens = append(tokens, token)
 		}
 	})
+	if ctx.Err() != nil {
+		return nil, false
+	}
+
+	converter := s.NewPositionConverter()
+	for i, token := range tokens {
+		rng := converter.FromByteRange(uri, lsp.Range{
+			Start: lsp.Position{Line: int(token.line), Character: int(token.char)},
+			End:   lsp.Position{Line: int(token.line), Character: int(token.char + token.length)},
+		})
+		tokens[i].char = uint(rng.Start.Character)
+		tokens[i].length = uint(rng.End.Character - rng.Start.Character)
+	}
+	return tokens, true
+}
+
+// full tokens, remembered for the next delta request
+func (s *Store) GetSemanticTokens(ctx context.Context, id Id, parse lsp.ParseFunction) lsp.SemantiTokens {
+	tokens, ok := s.getSemanticTokens(ctx, id, nil, parse)
+	if !ok {
+		return lsp.SemantiTokens{
+			Data: []uint{},
+		}
+	}
+	data := encodeSemanticTokens(tokens)
+	resultId := getSemanticTokensResultId(data)
+	s.cacheMu.Lock()
+	s.semanticTokens[id] = semanticTokensEntry{resultId: resultId, data: data}
+	s.cacheMu.Unlock()
+
+	return lsp.SemantiTokens{
+		ResultId: resultId,
+		Data:     data,
+	}
+}
 
+func (s *Store) GetSemanticTokensRange(ctx context.Context, id Id, rng lsp.Range, parse lsp.ParseFunction) lsp.SemantiTokens {
+	tokens, _ := s.getSemanticTokens(ctx, id, &rng, parse)
 	return lsp.SemantiTokens{
-		Data: intTokens,
+		Data: encodeSemanticTokens(tokens),
+	}
+}
+
+// single edit replacing what changed between common prefix and suffix, full tokens when previousResultId is stale
+func (s *Store) GetSemanticTokensDelta(ctx context.Context, id Id, previousResultId string, parse lsp.ParseFunction) any {
+	s.cacheMu.Lock()
+	prev, found := s.semanticTokens[id]
+	s.cacheMu.Unlock()
+	if !found || prev.resultId != previousResultId {
+		return s.GetSemanticTokens(ctx, id, parse)
+	}
+	full := s.GetSemanticTokens(ctx, id, parse)
+	delta := lsp.SemanticTokensDelta{
+		ResultId: full.ResultId,
+		Edits:    []lsp.SemanticTokensEdit{},
 	}
+	if full.ResultId == prev.resultId {
+		return delta
+	}
+
+	old, cur := prev.data, full.Data
+	start := 0
+	for start < len(old) && start < len(cur) && old[start] == cur[start] {
+		start++
+	}
+	end := 0
+	for end < len(old)-start && end < len(cur)-start && old[len(old)-1-end] == cur[len(cur)-1-end] {
+		end++
+	}
+	delta.Edits = append(delta.Edits, lsp.SemanticTokensEdit{
+		Start:       uint(start),
+		DeleteCount: uint(len(old) - start - end),
+		Data:        cur[start : len(cur)-end],
+	})
+	return delta
 }
@@ -0,0 +1,105 @@
+package data
+
+import (
+	"context"
+	"slices"
+	"sylmark/lsp"
+	"testing"
+)
+
+func TestSemanticTokensRange(t *testing.T) {
+	s, parse := newTestVault(t)
+	addTestNote(t, s, parse, "b.md", "# B\n")
+	a := addTestNote(t, s, parse, "a.md", "[b](b.md) x [c](c.md)\n[d](d.md) and [b](b.md)\n")
+
+	getStarts := func(rng *lsp.Range) (starts [][2]uint) {
+		tokens, _ := s.getSemanticTokens(context.Background(), a, rng, parse)
+		for _, token := range tokens {
+			starts = append(starts, [2]uint{token.line, token.char})
+		}
+		return starts
+	}
+	t.Run("1 All tokens without range", func(t *testing.T) {
+		want := [][2]uint{{0, 0}, {0, 12}, {1, 0}, {1, 14}}
+		if got := getStarts(nil); !slices.Equal(want, got) {
+			t.Errorf("Tokens >>> %v got %v", want, got)
+		}
+	})
+	t.Run("2 Tokens overlapping range only", func(t *testing.T) {
+		rng := lsp.Range{
+			Start: lsp.Position{Line: 0, Character: 5},
+			End:   lsp.Position{Line: 1, Character: 3},
+		}
+		want := [][2]uint{{0, 0}, {0, 12}, {1, 0}}
+		if got := getStarts(&rng); !slices.Equal(want, got) {
+			t.Errorf("Tokens >>> %v got %v", want, got)
+		}
+	})
+	t.Run("3 Token after range end on same line", func(t *testing.T) {
+		rng := lsp.Range{
+			Start: lsp.Position{Line: 1, Character: 9},
+			End:   lsp.Position{Line: 1, Character: 14},
+		}
+		if got := getStarts(&rng); len(got) != 0 {
+			t.Errorf("Tokens >>> [] got %v", got)
+		}
+	})
+}
+
+func TestSemanticTokensOverlap(t *testing.T) {
+	t.Run("1 Token within last one is left out", func(t *testing.T) {
+		tokens := []semanticToken{{line: 2, char: 4, length: 10}}
+		if !(semanticToken{line: 2, char: 8, length: 3}).within(tokens) {
+			t.Errorf("Token at 8 should be within 4-14")
+		}
+		if (semanticToken{line: 2, char: 14, length: 3}).within(tokens) {
+			t.Errorf("Token at 14 should not be within 4-14")
+		}
+		if (semanticToken{line: 3, char: 5, length: 3}).within(tokens) {
+			t.Errorf("Token on next line should not be within")
+		}
+	})
+	t.Run("2 Tag in link text", func(t *testing.T) {
+		s, parse := newTestVault(t)
+		a := addTestNote(t, s, parse, "a.md", "[see #tag](b.md) #other\n")
+		tokens, _ := s.getSemanticTokens(context.Background(), a, nil, parse)
+		var types []uint
+		for _, token := range tokens {
+			types = append(types, token.tokenType)
+		}
+		if want := []uint{fileLinkTokenType, tagTokenType}; !slices.Equal(want, types) {
+			t.Errorf("Token types >>> %v got %v", want, types)
+		}
+	})
+}
+
+func TestSemanticTokensDelta(t *testing.T) {
+	s, parse := newTestVault(t)
+	a := addTestNote(t, s, parse, "a.md", "[b](b.md)\n[c](c.md)\n")
+	full := s.GetSemanticTokens(context.Background(), a, parse)
+
+	t.Run("1 Unchanged gives no edits", func(t *testing.T) {
+		delta, ok := s.GetSemanticTokensDelta(context.Background(), a, full.ResultId, parse).(lsp.SemanticTokensDelta)
+		if !ok || delta.ResultId != full.ResultId || len(delta.Edits) != 0 {
+			t.Errorf("Delta >>> no edits got %+v", delta)
+		}
+	})
+	t.Run("2 Stale result id gives full tokens", func(t *testing.T) {
+		if _, ok := s.GetSemanticTokensDelta(context.Background(), a, "stale", parse).(lsp.SemantiTokens); !ok {
+			t.Errorf("Delta of stale id should be full tokens")
+		}
+	})
+	t.Run("3 Edit replaces changed tokens", func(t *testing.T) {
+		prev := s.GetSemanticTokens(context.Background(), a, parse)
+		s.SyncChangedDocument(a, lsp.TextDocumentContentChangeEvent{Text: "[b](b.md)\nx [c](c.md)\n"}, parse)
+		delta, ok := s.GetSemanticTokensDelta(context.Background(), a, prev.ResultId, parse).(lsp.SemanticTokensDelta)
+		if !ok || len(delta.Edits) != 1 {
+			t.Fatalf("Delta >>> 1 edit got %+v", delta)
+		}
+		edit := delta.Edits[0]
+		got := slices.Concat(prev.Data[:edit.Start], edit.Data, prev.Data[edit.Start+edit.DeleteCount:])
+		if cur := s.GetSemanticTokens(context.Background(), a, parse); !slices.Equal(cur.Data, got) {
+			t.Errorf("Edited tokens >>> %v got %v", cur.Data, got)
+		}
+	})
+}
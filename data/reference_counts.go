@@ -0,0 +1,159 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"sylmark/lsp"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// refs to the file or one of it's headings, within refs are [[#Heading]] of the same file
+type referenceCount struct {
+	Range      lsp.Range
+	SubTarget  SubTarget
+	Refs       int
+	WithinRefs int
+}
+
+func (c referenceCount) total() int {
+	return c.Refs + c.WithinRefs
+}
+
+func (c referenceCount) title() string {
+	title := "1 reference"
+	if c.total() != 1 {
+		title = fmt.Sprintf("%d references", c.total())
+	}
+	if c.WithinRefs > 0 {
+		title = fmt.Sprintf("%s (%d in file)", title, c.WithinRefs)
+	}
+	return title
+}
+
+func (s *Store) getHeadings(id Id, docData DocumentData, parse lsp.ParseFunction) *HeadingsStore {
+	if docData.Headings != nil {
+		return docData.Headings
+	}
+	return s.GetLoadedDataStore(id, parse)
+}
+
+// file refs at the start of file followed by every referenced atx heading and block
+func (s *Store) getReferenceCounts(id Id, parse lsp.ParseFunction) (counts []referenceCount) {
+	docData, ok := s.GetDocMustTree(id, parse)
+	if !ok {
+		return
+	}
+	content := string(docData.Content)
+	headings := s.getHeadings(id, docData, parse)
+
+	refs, _ := s.LinkStore.GetRefs(id, "")
+	if len(refs) > 0 {
+		counts = append(counts, referenceCount{Refs: len(refs)})
+	}
+
+	lsp.TraverseNodeWith(docData.Trees.GetMainTree().RootNode(), func(n *tree_sitter.Node) {
+		if n.Kind() != "atx_heading" {
+			return
+		}
+		subTarget, ok := GetSubTarget(n, content)
+		if !ok {
+			return
+		}
+		refs, _ := s.LinkStore.GetRefs(id, subTarget)
+		subRefs, _ := headings.GetRefs(string(subTarget))
+		count := referenceCount{
+			SubTarget:  subTarget,
+			Refs:       len(refs),
+			WithinRefs: len(subRefs),
+		}
+		if count.total() == 0 {
+			return
+		}
+		_, count.Range, _ = GetHeadingContent(n, content)
+		counts = append(counts, count)
+	})
+
+	for _, b := range getBlockIds(content, docData.Trees, allLines) {
+		refs, _ := s.LinkStore.GetRefs(id, b.subTarget)
+		subRefs, _ := headings.GetRefs(string(b.subTarget))
+		count := referenceCount{
+			SubTarget:  b.subTarget,
+			Refs:       len(refs),
+			WithinRefs: len(subRefs),
+			// marker at end of block
+			Range: lsp.Range{Start: b.rng.End, End: b.rng.End},
+		}
+		count.Range.Start.Character -= len(b.subTarget) - 1
+		if count.total() > 0 {
+			counts = append(counts, count)
+		}
+	}
+	return counts
+}
+
+// heading reference counts shown at the end of heading line
+func (s *Store) GetInlayHints(id Id, rng lsp.Range, parse lsp.ParseFunction) []lsp.InlayHint {
+	hints := []lsp.InlayHint{}
+	for _, count := range s.getReferenceCounts(id, parse) {
+		if len(count.SubTarget) == 0 {
+			// file refs are shown as code lens only
+			continue
+		}
+		if count.Range.End.Line < rng.Start.Line || count.Range.End.Line > rng.End.Line {
+			continue
+		}
+		hints = append(hints, lsp.InlayHint{
+			Position:    count.Range.End,
+			Label:       count.title(),
+			PaddingLeft: true,
+		})
+	}
+	return hints
+}
+
+// client side command showing locations like vscode does, arguments are uri, position and locations
+const ShowReferencesCommand = "editor.action.showReferences"
+
+// file and heading reference counts, clicking one shows it's references through ShowReferencesCommand
+func (s *Store) GetCodeLenses(ctx context.Context, id Id, parse lsp.ParseFunction) []lsp.CodeLens {
+	lenses := []lsp.CodeLens{}
+	uri, ok := s.GetUri(id)
+	if !ok {
+		return lenses
+	}
+	for _, count := range s.getReferenceCounts(id, parse) {
+		locs := s.GetReferenceLocations(ctx, id, count.SubTarget, parse)
+		lenses = append(lenses, lsp.CodeLens{
+			Range: count.Range,
+			Command: &lsp.Command{
+				Title:     count.title(),
+				Command:   ShowReferencesCommand,
+				Arguments: []any{uri, count.Range.Start, locs},
+			},
+		})
+	}
+	return lenses
+}
+
+// refs of file or it's heading when subTarget is given, including refs within file, nothing once ctx is done
+func (s *Store) GetReferenceLocations(ctx context.Context, id Id, subTarget SubTarget, parse lsp.ParseFunction) []lsp.Location {
+	locs := []lsp.Location{}
+	uri, ok := s.GetUri(id)
+	if !ok {
+		return locs
+	}
+	if len(subTarget) > 0 {
+		if docData, ok := s.GetDocMustTree(id, parse); ok {
+			subRefs, _ := s.getHeadings(id, docData, parse).GetRefs(string(subTarget))
+			for _, r := range subRefs {
+				locs = append(locs, lsp.Location{URI: uri, Range: r})
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return []lsp.Location{}
+	}
+	refs, _ := s.LinkStore.GetRefs(id, subTarget)
+	return *s.FillInLocations(&locs, &refs)
+}
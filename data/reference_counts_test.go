@@ -0,0 +1,86 @@
+package data
+
+import (
+	"context"
+	"slices"
+	"sylmark/lsp"
+	"testing"
+)
+
+func TestReferenceCounts(t *testing.T) {
+	s, parse := newTestVault(t)
+	b := addTestNote(t, s, parse, "b.md", "# B\n\n## Part\ntext ^blk\n\n## Lonely\n")
+	addTestNote(t, s, parse, "a.md", "[b](b.md) [p](b.md#Part) [p](b.md#Part)\n")
+	addTestNote(t, s, parse, "c.md", "[p](b.md#Part) [x](b.md#^blk)\n")
+	bUri := testNoteURI(s, "b.md")
+	rng := func(line, start, end int) lsp.Range {
+		return lsp.Range{Start: lsp.Position{Line: line, Character: start}, End: lsp.Position{Line: line, Character: end}}
+	}
+
+	t.Run("1 File, referenced headings and blocks", func(t *testing.T) {
+		// file counts links to it's headings and blocks too
+		want := []referenceCount{
+			{Refs: 5},
+			{Range: rng(2, 3, 7), SubTarget: "#Part", Refs: 3},
+			{Range: rng(3, 5, 9), SubTarget: "#^blk", Refs: 1},
+		}
+		if got := s.getReferenceCounts(b, parse); !slices.Equal(got, want) {
+			t.Errorf("Counts >>> %v got %v", want, got)
+		}
+	})
+	t.Run("2 Titles", func(t *testing.T) {
+		tests := map[string]referenceCount{
+			"1 reference":              {Refs: 1},
+			"2 references":             {Refs: 2},
+			"3 references (1 in file)": {Refs: 2, WithinRefs: 1},
+			"1 reference (1 in file)":  {WithinRefs: 1},
+		}
+		for want, count := range tests {
+			if got := count.title(); got != want {
+				t.Errorf("Title >>> [%s] got [%s]", want, got)
+			}
+		}
+	})
+	t.Run("3 Inlay hints at end of headings and markers within range", func(t *testing.T) {
+		var got []string
+		for _, hint := range s.GetInlayHints(b, lsp.Range{End: lsp.Position{Line: 10}}, parse) {
+			got = append(got, hint.Label)
+			if !hint.PaddingLeft {
+				t.Errorf("%s should be padded", hint.Label)
+			}
+		}
+		if want := []string{"3 references", "1 reference"}; !slices.Equal(got, want) {
+			t.Errorf("Hints >>> %v got %v", want, got)
+		}
+		hints := s.GetInlayHints(b, rng(3, 0, 0), parse)
+		if len(hints) != 1 || hints[0].Position != (lsp.Position{Line: 3, Character: 9}) {
+			t.Errorf("Hints of line 3 >>> at 3:9 got %v", hints)
+		}
+	})
+	t.Run("4 Code lenses show references through client", func(t *testing.T) {
+		lenses := s.GetCodeLenses(context.Background(), b, parse)
+		if len(lenses) != 3 {
+			t.Fatalf("Lenses >>> 3 got %v", lenses)
+		}
+		for i, want := range []struct {
+			title string
+			pos   lsp.Position
+			refs  int
+		}{
+			{"5 references", lsp.Position{}, 5},
+			{"3 references", lsp.Position{Line: 2, Character: 3}, 3},
+			{"1 reference", lsp.Position{Line: 3, Character: 5}, 1},
+		} {
+			command := lenses[i].Command
+			if command.Title != want.title || command.Command != ShowReferencesCommand {
+				t.Errorf("Command >>> %s %s got %s %s", want.title, ShowReferencesCommand, command.Title, command.Command)
+			}
+			if len(command.Arguments) != 3 || command.Arguments[0] != bUri || command.Arguments[1] != want.pos {
+				t.Fatalf("Arguments >>> %s %v got %v", bUri, want.pos, command.Arguments)
+			}
+			if locs := command.Arguments[2].([]lsp.Location); len(locs) != want.refs {
+				t.Errorf("Locations of %s >>> %d got %v", want.title, want.refs, locs)
+			}
+		}
+	})
+}
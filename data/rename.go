@@ -0,0 +1,340 @@
+package data
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sylmark/lsp"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// returns the link_destination of wiki_link, inline_link or image
+func getLinkDestinationNode(node *tree_sitter.Node) *tree_sitter.Node {
+	switch node.Kind() {
+	case "wiki_link":
+		return node.NamedChild(0)
+	case "inline_link", "image":
+		for i := range node.NamedChildCount() {
+			child := node.NamedChild(i)
+			if child.Kind() == "link_destination" {
+				return child
+			}
+		}
+	}
+	return nil
+}
+
+// finds wiki_link, inline_link or image node at rng, refs in LinkStore or HeadingsStore are never images
+func (s *Store) getLinkNodeAt(docData DocumentData, rng lsp.Range) (*tree_sitter.Node, bool) {
+	if docData.Trees == nil {
+		return nil, false
+	}
+	node := docData.Trees.GetInlineTree().RootNode().NamedDescendantForPointRange(lsp.PointFromPosition(rng.Start), lsp.PointFromPosition(rng.End))
+	if node == nil {
+		return nil, false
+	}
+	node = lsp.GetParentalKind(node)
+	switch node.Kind() {
+	case "wiki_link", "inline_link", "image":
+		return node, true
+	}
+	return nil, false
+}
+
+// link_destination of the wiki_link, embed or inline_link stored at loc
+func (s *Store) getLinkDestinationAt(loc IdLocation, parse lsp.ParseFunction) (uri lsp.DocumentURI, linkKind string, destRng lsp.Range, dest string, ok bool) {
+	uri, ok = s.GetUri(loc.Id)
+	if !ok {
+		return
+	}
+	// embeds are not nodes, their refs start at !
+	if dest, destRng, ok := s.getEmbedDestinationAt(loc.Id, loc.Range); ok {
+		return uri, "wiki_link", destRng, dest, true
+	}
+	docData, ok := s.GetDocMustTree(loc.Id, parse)
+	if !ok {
+		return
+	}
+	node, ok := s.getLinkNodeAt(docData, loc.Range)
+	if !ok {
+		return
+	}
+	destNode := getLinkDestinationNode(node)
+	if destNode == nil {
+		return uri, "", destRng, "", false
+	}
+	dest = lsp.GetNodeContent(*destNode, string(docData.Content))
+	return uri, node.Kind(), lsp.GetRange(destNode), dest, true
+}
+
+// edit which replaces the part after # of the link at loc with heading
+func (s *Store) getSubTargetEdit(loc IdLocation, heading string, parse lsp.ParseFunction) (uri lsp.DocumentURI, edit lsp.TextEdit, ok bool) {
+	uri, linkKind, destRng, dest, ok := s.getLinkDestinationAt(loc, parse)
+	if !ok {
+		return
+	}
+	hashI := strings.IndexRune(dest, '#')
+	if hashI == -1 {
+		return uri, edit, false
+	}
+
+	newText := heading
+	if linkKind == "inline_link" {
+		newText = s.encodeForInlineLinkdownLinkPath(heading)
+	}
+	rng := destRng
+	rng.Start.Character += hashI + 1
+	return uri, lsp.TextEdit{
+		Range:   rng,
+		NewText: newText,
+	}, true
+}
+
+// edit which replaces the part before # of the link at loc so that it points to newUri
+func (s *Store) getTargetEdit(loc IdLocation, oldUri lsp.DocumentURI, newUri lsp.DocumentURI, parse lsp.ParseFunction) (uri lsp.DocumentURI, edit lsp.TextEdit, ok bool) {
+	uri, linkKind, destRng, dest, ok := s.getLinkDestinationAt(loc, parse)
+	if !ok {
+		return
+	}
+	target, _, _ := strings.Cut(dest, "#")
+	if len(target) == 0 {
+		return uri, edit, false
+	}
+
+	var newTarget string
+	switch linkKind {
+	case "wiki_link":
+		t, ok := s.getRenamedWikiTarget(Target(target), oldUri, newUri)
+		if !ok {
+			return uri, edit, false
+		}
+		newTarget = string(t)
+	case "inline_link":
+		sourceUri := uri
+		if uri == oldUri {
+			// link to itself, will be relative to new place
+			sourceUri = newUri
+		}
+		sourceDir, err := GetDirPathFromURI(sourceUri)
+		if err != nil {
+			return uri, edit, false
+		}
+		newPath, err := PathFromURI(newUri)
+		if err != nil {
+			return uri, edit, false
+		}
+		newTarget, ok = s.getRenamedInlineTarget(target, sourceDir, newPath)
+		if !ok {
+			return uri, edit, false
+		}
+	}
+
+	rng := destRng
+	rng.End = rng.Start
+	rng.End.Character += len(target)
+	return uri, lsp.TextEdit{
+		Range:   rng,
+		NewText: newTarget,
+	}, true
+}
+
+// edits for relative inline links of the moved file itself, since they are relative to it's directory
+func (s *Store) getMovedFileLinkEdits(id Id, oldUri lsp.DocumentURI, newUri lsp.DocumentURI, parse lsp.ParseFunction) (edits []lsp.TextEdit) {
+	oldPath, err := PathFromURI(oldUri)
+	if err != nil {
+		return
+	}
+	newPath, err := PathFromURI(newUri)
+	if err != nil {
+		return
+	}
+	oldDir := filepath.Dir(oldPath)
+	newDir := filepath.Dir(newPath)
+	// web mode links are relative to page urls not directories
+	if oldDir == newDir || s.Config.MdLinkWebMode {
+		return
+	}
+	docData, ok := s.GetDocMustTree(id, parse)
+	if !ok {
+		return
+	}
+	content := string(docData.Content)
+	lsp.TraverseNodeWith(docData.Trees.GetInlineTree().RootNode(), func(n *tree_sitter.Node) {
+		if n.Kind() != "inline_link" && n.Kind() != "image" {
+			return
+		}
+		destNode := getLinkDestinationNode(n)
+		if destNode == nil {
+			return
+		}
+		target, _, _ := strings.Cut(lsp.GetNodeContent(*destNode, content), "#")
+		if len(target) == 0 || strings.Contains(target, ":") || filepath.IsAbs(target) {
+			// urls, file:// and absolute paths are not relative
+			return
+		}
+		path := filepath.Join(oldDir, s.DecodeForInlineLinkdownLinkPath(target))
+		if path == oldPath || path == RemoveMdExtOnly(oldPath) {
+			// link to itself is taken care by it's refs
+			return
+		}
+		if _, err := os.Stat(path); err != nil {
+			path = GetInlineTargetUrl(path)
+			if _, err := os.Stat(path); err != nil {
+				return
+			}
+		}
+		newTarget, ok := s.getRenamedInlineTarget(target, newDir, path)
+		if !ok {
+			return
+		}
+		rng := lsp.GetRange(destNode)
+		rng.End = rng.Start
+		rng.End.Character += len(target)
+		edits = append(edits, lsp.TextEdit{
+			Range:   rng,
+			NewText: newTarget,
+		})
+	})
+	return edits
+}
+
+// rewrites every wikilink and inline link pointing to renamed files, and relative links of moved files
+func (s *Store) GetFilesRenameEdits(files []lsp.FileRename, parse lsp.ParseFunction) (edit lsp.WorkspaceEdit) {
+	edit.Changes = map[lsp.DocumentURI][]lsp.TextEdit{}
+	for _, file := range files {
+		oldUri, _ := CleanUpURI(string(file.OldUri))
+		newUri, _ := CleanUpURI(string(file.NewUri))
+		if !IsMdFile(string(oldUri)) || !IsMdFile(string(newUri)) {
+			continue
+		}
+		id, found := s.FindIdFromURI(oldUri)
+		if !found {
+			continue
+		}
+
+		refs, _ := s.LinkStore.GetRefs(id, "")
+		for _, loc := range refs {
+			uri, textEdit, ok := s.getTargetEdit(loc, oldUri, newUri, parse)
+			if ok {
+				edit.Changes[uri] = append(edit.Changes[uri], textEdit)
+			}
+		}
+
+		edits := s.getMovedFileLinkEdits(id, oldUri, newUri, parse)
+		if len(edits) > 0 {
+			edit.Changes[oldUri] = append(edit.Changes[oldUri], edits...)
+		}
+	}
+	return edit
+}
+
+// renames atx heading node of id and rewrites every wikilink and inline link pointing to it
+func (s *Store) GetHeadingRenameEdits(id Id, node *tree_sitter.Node, newName string, parse lsp.ParseFunction) (edit lsp.WorkspaceEdit, err error) {
+	docData, ok := s.GetDocMustTree(id, parse)
+	if !ok {
+		return edit, fmt.Errorf("Document not found")
+	}
+	content := string(docData.Content)
+	uri, _ := s.GetUri(id)
+
+	newName = strings.TrimSpace(newName)
+	if len(newName) == 0 {
+		return edit, fmt.Errorf("Heading can not be empty")
+	}
+	subTarget, ok := GetSubTarget(node, content)
+	if !ok {
+		return edit, fmt.Errorf("Not a heading")
+	}
+	newSubTarget := SubTarget("#" + newName)
+	if newSubTarget == subTarget {
+		return edit, nil
+	}
+	headings := docData.Headings
+	if headings == nil {
+		headings = s.GetLoadedDataStore(id, parse)
+	}
+	if _, found := headings.GetDef(string(newSubTarget)); found {
+		return edit, fmt.Errorf("Heading `%s` already exists", newName)
+	}
+
+	edit.Changes = map[lsp.DocumentURI][]lsp.TextEdit{}
+	_, rng, _ := GetHeadingContent(node, content)
+	edit.Changes[uri] = append(edit.Changes[uri], lsp.TextEdit{
+		Range:   rng,
+		NewText: newName,
+	})
+
+	// [[#Heading]] within file
+	var locs []IdLocation
+	subRefs, _ := headings.GetRefs(string(subTarget))
+	for _, r := range subRefs {
+		locs = append(locs, IdLocation{Id: id, Range: r})
+	}
+	// [[Note#Heading]] and [text](note.md#heading), inline ones are stored encoded
+	refs, _ := s.LinkStore.GetRefs(id, subTarget)
+	locs = append(locs, refs...)
+	encodedSubTarget := SubTarget(s.encodeForInlineLinkdownLinkPath(string(subTarget)))
+	if encodedSubTarget != subTarget {
+		encodedRefs, _ := s.LinkStore.GetRefs(id, encodedSubTarget)
+		locs = append(locs, encodedRefs...)
+	}
+
+	for _, loc := range locs {
+		refUri, textEdit, ok := s.getSubTargetEdit(loc, newName, parse)
+		if ok {
+			edit.Changes[refUri] = append(edit.Changes[refUri], textEdit)
+		}
+	}
+
+	return edit, nil
+}
+
+// same form of wiki target for newUri as target was for oldUri i.e. plain, one up or vault
+func (s *Store) getRenamedWikiTarget(target Target, oldUri lsp.DocumentURI, newUri lsp.DocumentURI) (Target, bool) {
+	oldVaultTarget, ok := s.GetVaultTarget(oldUri)
+	if !ok {
+		return "", false
+	}
+	newVaultTarget, ok := s.GetVaultTarget(newUri)
+	if !ok {
+		return "", false
+	}
+	if target == oldVaultTarget {
+		return newVaultTarget, true
+	}
+	oldOneUpTarget, _ := GetOneUpTarget(oldVaultTarget)
+	if target == oldOneUpTarget {
+		newOneUpTarget, _ := GetOneUpTarget(newVaultTarget)
+		return newOneUpTarget, true
+	}
+	oldPlainTarget, _ := GetPlainTarget(oldVaultTarget)
+	if target == oldPlainTarget {
+		// plain link shared by multiple files, it should keep pointing to others
+		if ids, _ := s.getValidIds(target); len(ids) > 1 {
+			return "", false
+		}
+		newPlainTarget, _ := GetPlainTarget(newVaultTarget)
+		return newPlainTarget, true
+	}
+	return "", false
+}
+
+// relative inline link path from sourceDir to path, keeps .md and ./ as they were in oldLinkPath
+func (s *Store) getRenamedInlineTarget(oldLinkPath string, sourceDir string, path string) (string, bool) {
+	relPath, err := s.getInlineRelFormattedTarget(sourceDir, path)
+	if err != nil {
+		return "", false
+	}
+	if IsMdFile(path) {
+		relPath = RemoveMdExtOnly(relPath)
+		if IsMdFile(oldLinkPath) {
+			relPath += ".md"
+		}
+	}
+	if strings.HasPrefix(oldLinkPath, "./") && !strings.HasPrefix(relPath, ".") {
+		relPath = "./" + relPath
+	}
+	return relPath, true
+}
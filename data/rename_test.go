@@ -0,0 +1,187 @@
+package data
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sylmark/lsp"
+	"testing"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// atx_heading node on line of note id
+func getTestHeadingNode(t *testing.T, s *Store, parse lsp.ParseFunction, id Id, line int) *tree_sitter.Node {
+	doc, ok := s.GetDocMustTree(id, parse)
+	if !ok {
+		t.Fatalf("Doc %d not found", id)
+	}
+	var heading *tree_sitter.Node
+	lsp.TraverseNodeWith(doc.Trees.GetMainTree().RootNode(), func(n *tree_sitter.Node) {
+		if n.Kind() == "atx_heading" && n.StartPosition().Row == uint(line) {
+			heading = n
+		}
+	})
+	if heading == nil {
+		t.Fatalf("No heading on line %d", line)
+	}
+	return heading
+}
+
+// edits as "name line:character new text" sorted
+func getTestEdits(s *Store, edit lsp.WorkspaceEdit) []string {
+	var edits []string
+	for uri, textEdits := range edit.Changes {
+		path, _ := s.GetPathRelRoot(uri)
+		for _, e := range textEdits {
+			edits = append(edits, fmt.Sprintf("%s %d:%d %s", path, e.Range.Start.Line, e.Range.Start.Character, e.NewText))
+		}
+	}
+	slices.Sort(edits)
+	return edits
+}
+
+func TestHeadingRenameEdits(t *testing.T) {
+	s, parse := newTestVault(t)
+	b := addTestNote(t, s, parse, "b.md", "# B\n\n## Part One\ntext\n\n## Other\n")
+	addTestNote(t, s, parse, "a.md", "see [p](b.md#Part%20One) and [o](b.md#Other)\n")
+	addTestNote(t, s, parse, "dir/c.md", "see [p](../b.md#Part%20One)\n")
+	s.OpenDoc(b, "# B\n\n## Part One\ntext\n\n## Other\n", parse)
+
+	tests := []struct {
+		name    string
+		line    int
+		newName string
+		want    []string
+		err     bool
+	}{
+		{"1 Heading and inline links", 2, " Second Part ", []string{
+			"a.md 0:13 Second%20Part",
+			"b.md 2:3 Second Part",
+			"dir/c.md 0:16 Second%20Part",
+		}, false},
+		{"2 Same name", 2, "Part One", nil, false},
+		{"3 Empty name", 2, "  ", nil, true},
+		{"4 Heading exists", 2, "Other", nil, true},
+		{"5 Heading without refs", 0, "Beta", []string{"b.md 0:2 Beta"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edit, err := s.GetHeadingRenameEdits(b, getTestHeadingNode(t, s, parse, b, tt.line), tt.newName, parse)
+			if (err != nil) != tt.err {
+				t.Fatalf("Error >>> %v got %v", tt.err, err)
+			}
+			if got := getTestEdits(s, edit); !slices.Equal(got, tt.want) {
+				t.Errorf("Edits >>> %v got %v", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("6 Wikilinks within and to note", func(t *testing.T) {
+		addTestNote(t, s, parse, "d.md", "see [[b#Part One]] and [[b#Part One|alias]]\n")
+		s.OpenDoc(b, "# B\n\n## Part One\nsee [[#Part One]]\n\n## Other\n", parse)
+		edit, err := s.GetHeadingRenameEdits(b, getTestHeadingNode(t, s, parse, b, 2), "Second", parse)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{
+			"a.md 0:13 Second",
+			"b.md 2:3 Second",
+			"b.md 3:7 Second",
+			"d.md 0:8 Second",
+			"d.md 0:27 Second",
+			"dir/c.md 0:16 Second",
+		}
+		if got := getTestEdits(s, edit); !slices.Equal(got, want) {
+			t.Errorf("Edits >>> %v got %v", want, got)
+		}
+	})
+}
+
+func TestHeadingRenameEditsWebMode(t *testing.T) {
+	s, parse := newTestVault(t)
+	s.Config.MdLinkWebMode = true
+	b := addTestNote(t, s, parse, "b.md", "# B\n\n## Part One\n")
+	addTestNote(t, s, parse, "a.md", "see [p](b.md#part-one)\n")
+	s.OpenDoc(b, "# B\n\n## Part One\n", parse)
+
+	edit, err := s.GetHeadingRenameEdits(b, getTestHeadingNode(t, s, parse, b, 2), "Second Part", parse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a.md 0:13 second-part", "b.md 2:3 Second Part"}
+	if got := getTestEdits(s, edit); !slices.Equal(got, want) {
+		t.Errorf("Edits >>> %v got %v", want, got)
+	}
+}
+
+func TestFilesRenameEdits(t *testing.T) {
+	s, parse := newTestVault(t)
+	b := addTestNote(t, s, parse, "dir/b.md", "# B\n## Part\n[a](../a.md) ![i](img.png) [w](https://x.y/z.md) [p](#Part)\n")
+	addTestNote(t, s, parse, "a.md", "see [b](dir/b.md) and [p](./dir/b.md#Part)\n")
+	addTestNote(t, s, parse, "other/c.md", "see [b](../dir/b.md#Part)\n")
+	if err := os.WriteFile(filepath.Join(s.Config.RootPath, "dir/img.png"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		old, new string
+		want     []string
+	}{
+		{"1 Moved to other directory", "dir/b.md", "new/sub/b2.md", []string{
+			"a.md 0:26 ./new/sub/b2.md",
+			"a.md 0:8 new/sub/b2.md",
+			"dir/b.md 2:18 ../../dir/img.png",
+			"dir/b.md 2:4 ../../a.md",
+			"other/c.md 0:8 ../new/sub/b2.md",
+		}},
+		{"2 Renamed within directory", "dir/b.md", "dir/b2.md", []string{
+			"a.md 0:26 ./dir/b2.md",
+			"a.md 0:8 dir/b2.md",
+			"other/c.md 0:8 ../dir/b2.md",
+		}},
+		{"3 Not a note", "dir/img.png", "dir/pic.png", nil},
+		{"4 Unknown note", "missing.md", "found.md", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edit := s.GetFilesRenameEdits([]lsp.FileRename{{
+				OldUri: testNoteURI(s, tt.old),
+				NewUri: testNoteURI(s, tt.new),
+			}}, parse)
+			if got := getTestEdits(s, edit); !slices.Equal(got, tt.want) {
+				t.Errorf("Edits >>> %v got %v", tt.want, got)
+			}
+		})
+	}
+	t.Run("5 Links of moved file are kept in web mode", func(t *testing.T) {
+		s.Config.MdLinkWebMode = true
+		defer func() { s.Config.MdLinkWebMode = false }()
+		if edits := s.getMovedFileLinkEdits(b, testNoteURI(s, "dir/b.md"), testNoteURI(s, "new/b.md"), parse); len(edits) != 0 {
+			t.Errorf("Edits >>> [] got %v", edits)
+		}
+	})
+}
+
+func TestFilesRenameWikiEdits(t *testing.T) {
+	s, parse := newTestVault(t)
+	addTestNote(t, s, parse, "x/y/b.md", "# B\n## Part\n")
+	addTestNote(t, s, parse, "a.md", "see [[b]] [[y/b]] [[x/y/b#Part]]\n")
+	rename := []lsp.FileRename{{OldUri: testNoteURI(s, "x/y/b.md"), NewUri: testNoteURI(s, "x/z/b2.md")}}
+
+	t.Run("1 Plain, one up and vault targets keep their form", func(t *testing.T) {
+		want := []string{"a.md 0:12 z/b2", "a.md 0:20 x/z/b2", "a.md 0:6 b2"}
+		if got := getTestEdits(s, s.GetFilesRenameEdits(rename, parse)); !slices.Equal(got, want) {
+			t.Errorf("Edits >>> %v got %v", want, got)
+		}
+	})
+	t.Run("2 Plain target shared with other note is kept", func(t *testing.T) {
+		addTestNote(t, s, parse, "q/b.md", "# Q\n")
+		want := []string{"a.md 0:12 z/b2", "a.md 0:20 x/z/b2"}
+		if got := getTestEdits(s, s.GetFilesRenameEdits(rename, parse)); !slices.Equal(got, want) {
+			t.Errorf("Edits >>> %v got %v", want, got)
+		}
+	})
+}
@@ -2,9 +2,10 @@ package data
 
 import (
 	"log/slog"
+	"slices"
+	"strings"
 	"sylmark/lsp"
-
-	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+	"sync"
 )
 
 type Store struct {
@@ -18,6 +19,66 @@ type Store struct {
 	ExcerptLength int16
 	Config        Config
 	OtherFiles    []string
+
+	// front matter keys and values of every note, kept like Tags
+	FrontMatterKeys   map[string][]lsp.Location
+	FrontMatterValues map[string]map[string][]lsp.Location
+
+	// task list items of every note, loaded like other doc entries
+	Tasks map[Id][]Task
+
+	// characters of positions exchanged with client, stores always use byte columns
+	PositionEncoding lsp.PositionEncodingKind
+
+	// guards every map of the store, shared by lsp handler and graph server
+	mu *sync.RWMutex
+
+	// docs read and trees parsed by readers, DocStore itself changes only under the write lock
+	readDocs   map[Id]DocumentData
+	readDocsMu *sync.Mutex
+
+	// guards diagnosticLinks and semanticTokens which readers fill in
+	cacheMu *sync.Mutex
+
+	// documents opened by client, others don't keep their trees
+	openDocs map[Id]bool
+
+	// links of documents by content hash for workspace diagnostics
+	diagnosticLinks map[Id]diagnosticLinksEntry
+
+	// last full semantic tokens sent for a document, base of delta requests
+	semanticTokens map[Id]semanticTokensEntry
+
+	// entries loaded for each document, edits are synced against them
+	docEntries map[Id][]docEntry
+
+	// definitions added or removed, open documents linking to them need fresh diagnostics
+	changedDefs map[Id]map[SubTarget]bool
+
+	// from front matter, aliases are registered in TargetStore as well
+	aliases map[Id][]Target
+	titles  map[Id]string
+
+	// front matter tags written without #
+	tagsWithoutHash map[lsp.Location]bool
+}
+
+// held for whole requests which may change the store
+func (s *Store) Lock() {
+	s.mu.Lock()
+}
+
+func (s *Store) Unlock() {
+	s.mu.Unlock()
+}
+
+// readers must stick to lookups, GetIdFromURI creates ids and getIds shadow ids
+func (s *Store) RLock() {
+	s.mu.RLock()
+}
+
+func (s *Store) RUnlock() {
+	s.mu.RUnlock()
 }
 
 func NewStore() Store {
@@ -31,136 +92,139 @@ func NewStore() Store {
 		OtherFiles:    []string{},
 		Config:        NewConfig(),
 		ExcerptLength: 10,
+
+		FrontMatterKeys:   map[string][]lsp.Location{},
+		FrontMatterValues: map[string]map[string][]lsp.Location{},
+
+		Tasks: map[Id][]Task{},
+
+		PositionEncoding: lsp.PositionEncodingUTF16,
+
+		mu:              &sync.RWMutex{},
+		readDocs:        map[Id]DocumentData{},
+		readDocsMu:      &sync.Mutex{},
+		cacheMu:         &sync.Mutex{},
+		openDocs:        map[Id]bool{},
+		diagnosticLinks: map[Id]diagnosticLinksEntry{},
+		semanticTokens:  map[Id]semanticTokensEntry{},
+		docEntries:      map[Id][]docEntry{},
+		changedDefs:     map[Id]map[SubTarget]bool{},
+		aliases:         map[Id][]Target{},
+		titles:          map[Id]string{},
+		tagsWithoutHash: map[lsp.Location]bool{},
 	}
 }
 
-func (s *Store) SyncChangedDocument(id Id, changes lsp.TextDocumentContentChangeEvent, parse lsp.ParseFunction) {
-
-	var updatedDocData, oldDocData DocumentData
-	// update data into openedDocs
-	doc := Document(changes.Text)
+// applies full or range change, range changes reparse incrementally and only entries around the edit are synced into stores
+func (s *Store) SyncChangedDocument(id Id, change lsp.TextDocumentContentChangeEvent, parse lsp.ParseFunction) {
 	staleDoc, ok := s.GetDocMustTree(id, parse)
-	oldDocData = staleDoc
 	if !ok {
 		slog.Error("Failed to get old file")
 		return
 	}
-	updatedDocData = *s.UpdateAndReloadDoc(id, string(doc), parse)
-	s.UnloadData(id, string(oldDocData.Content), oldDocData.Trees)
-	s.LoadData(id, string(updatedDocData.Content), updatedDocData.Trees)
-
-	// sylopti this is for lsp.TDSKIncremental
-	// TextDocumentSync is set to TDSKFull so this case won't be there but in future let's implment partial for better perf
-	// utils.Sprintf("RangeLength changed %d", changes.RangeLength)
-	// slog.Error("Need to handle partial change text")
-	// // return
-	// utils.Sprintf(fmt.Sprintf("range length %d", changes.RangeLength)
-	// utils.Sprintf
-	// 	"range line start %d end %d",
-	// 	changes.Range.Start.Line,
-	// 	changes.Range.End.Line,
-	// ))
-	// utils.Sprintf
-	// 	"range char start %d end %d",
-	// 	changes.Range.Start.Character,
-	// 	changes.Range.End.Character,
-	// ))
+	oldEntries, loaded := s.docEntries[id]
+	if !loaded {
+		oldEntries = s.getDocEntries(id, string(staleDoc.Content), staleDoc.Trees)
+	}
+
+	if change.Range == nil {
+		updatedDocData := s.UpdateAndReloadDoc(id, change.Text, nil, parse)
+		newEntries := s.getDocEntries(id, string(updatedDocData.Content), updatedDocData.Trees)
+		s.syncEntries(id, oldEntries, newEntries)
+		s.docEntries[id] = newEntries
+		return
+	}
+
+	doc, edit := staleDoc.Content.ApplyChange(*change.Range, change.Text)
+	staleDoc.Trees.GetMainTree().Edit(&edit)
+	staleDoc.Trees.GetInlineTree().Edit(&edit)
+	// reparsing closes edited trees, changed ranges are taken from copies
+	editedTrees := lsp.Trees{staleDoc.Trees.GetMainTree().Clone(), staleDoc.Trees.GetInlineTree().Clone()}
+	defer editedTrees.Close()
+	updatedDocData := s.UpdateAndReloadDoc(id, string(doc), staleDoc.Trees, parse)
+
+	span := lineSpan{start: int(edit.StartPosition.Row), end: int(edit.NewEndPosition.Row)}
+	for i, tree := range editedTrees {
+		for _, r := range tree.ChangedRanges(updatedDocData.Trees[i]) {
+			span = span.grow(int(r.StartPoint.Row), int(r.EndPoint.Row))
+		}
+	}
+	delta := int(edit.NewEndPosition.Row) - int(edit.OldEndPosition.Row)
+	s.docEntries[id] = s.syncEditedEntries(id, oldEntries, string(updatedDocData.Content), updatedDocData.Trees, span, delta)
 }
 
-func (s *Store) UnloadData(id Id, content string, trees *lsp.Trees) {
-	// utils.Sprintf("UnloadData id=%d", id)
-	uri, _ := s.GetUri(id)
-	lsp.TraverseNodeWith(trees.GetMainTree().RootNode(), func(n *tree_sitter.Node) {
-		switch n.Kind() {
-		case "atx_heading":
-			{
-				subTarget, ok := GetSubTarget(n, content)
-				if ok {
-					s.LinkStore.RemoveDef(id, subTarget, lsp.GetRange(n))
-				}
-			}
+// entries within span are synced, ones below it are shifted by delta lines, span is in lines of content
+func (s *Store) syncEditedEntries(id Id, oldEntries []docEntry, content string, trees *lsp.Trees, span lineSpan, delta int) []docEntry {
+	lastContentLine := strings.Count(content, "\n")
+	var above, affected, below, newEntries []docEntry
+	for {
+		oldSpan := lineSpan{start: span.start, end: span.end - delta}
+		above, affected, below = splitEntries(oldEntries, oldSpan)
+		grown := span
+		for _, e := range affected {
+			grown = grown.grow(e.Range.Start.Line, lastLine(e.Range)+delta)
 		}
-	})
-	lsp.TraverseNodeWith(trees.GetInlineTree().RootNode(), func(n *tree_sitter.Node) {
-		switch n.Kind() {
-		case "wiki_link":
-			{
-				target, subTarget, _, ok := GetWikilinkTargets(n, content)
-				if ok {
-					ids := s.getIds(target)
-					for _, defId := range ids {
-						loc := id.LocationOf(n)
-						s.LinkStore.RemoveRef(defId, subTarget, loc)
-					}
+		newEntries = s.getDocEntriesWithin(id, content, trees, grown, getHeadingAbove(above))
+		for _, e := range newEntries {
+			grown = grown.grow(e.Range.Start.Line, lastLine(e.Range))
+		}
+		// tasks till next heading are under the changed one
+		if slices.ContainsFunc(affected, isHeadingEntry) || slices.ContainsFunc(newEntries, isHeadingEntry) {
+			next := lastContentLine
+			for _, e := range below {
+				if isHeadingEntry(e) {
+					next = min(next, e.Range.Start.Line+delta-1)
 				}
 			}
-		case "tag":
-			{
-				s.RemoveTag(n, uri, &content)
-			}
-		case "inline_link":
-			subTarget := SubTarget("")
-			_, linkId, subTarget, found := s.GetInlineFullTargetAndSubTarget(n, content, id)
-			if found {
-				loc := id.LocationOf(n)
-				s.LinkStore.RemoveRef(linkId, subTarget, loc)
-			}
+			grown = grown.grow(grown.start, next)
+		}
+		if grown == span {
+			break
 		}
-	})
+		span = grown
+	}
+	s.syncEntries(id, affected, newEntries)
+	return slices.Concat(above, newEntries, s.shiftEntries(id, below, delta))
+}
+
+func (s *Store) UnloadData(id Id, content string, trees *lsp.Trees) {
+	// utils.Sprintf("UnloadData id=%d", id)
+	uri, _ := s.GetUri(id)
+	entries, loaded := s.docEntries[id]
+	if !loaded {
+		entries = s.getDocEntries(id, content, trees)
+	}
+	defs := s.getDefSubTargets(id)
+	for _, e := range entries {
+		s.unloadEntry(id, uri, e)
+	}
+	delete(s.docEntries, id)
+	s.markDefsChangedSince(id, defs)
 }
 
 func (s *Store) LoadData(id Id, content string, trees *lsp.Trees) {
 	// utils.Sprintf("LoadData id=%d", id)
+	s.loadEntries(id, s.getDocEntries(id, content, trees))
+}
 
+func (s *Store) loadEntries(id Id, entries []docEntry) {
 	uri, _ := s.GetUri(id)
+	defs := s.getDefSubTargets(id)
 	s.LinkStore.AddFileGTarget(id)
-	lsp.TraverseNodeWith(trees.GetMainTree().RootNode(), func(n *tree_sitter.Node) {
-		switch n.Kind() {
-		case "atx_heading":
-			{
-				subTarget, ok := GetSubTarget(n, content)
-				if ok {
-					s.LinkStore.AddDef(id, subTarget, lsp.GetRange(n))
-				}
-			}
-		}
-	})
-
-	lsp.TraverseNodeWith(trees.GetInlineTree().RootNode(), func(n *tree_sitter.Node) {
-		switch n.Kind() {
-		case "wiki_link":
-			{
-				target, subTarget, _, ok := GetWikilinkTargets(n, content)
-
-				if ok {
-					isSubheading := len(target) == 0
-					if !isSubheading {
-						defIds := s.getIds(target)
-						for _, defId := range defIds {
-							loc := id.LocationOf(n)
-							s.LinkStore.AddRef(defId, subTarget, loc)
-						}
-					}
-				}
-			}
-		case "tag":
-			{
-				s.AddTag(n, uri, &content)
-			}
-		case "inline_link":
-			subTarget := SubTarget("")
-			_, linkId, subTarget, found := s.GetInlineFullTargetAndSubTarget(n, content, id)
-			if found {
-				loc := id.LocationOf(n)
-				s.LinkStore.AddRef(linkId, subTarget, loc)
-			}
-		}
-	})
+	for _, e := range entries {
+		s.loadEntry(id, uri, e)
+	}
+	s.docEntries[id] = entries
+	s.markDefsChangedSince(id, defs)
 }
 
-func (s *Store) UpdateAndReloadDoc(id Id, content string, parse lsp.ParseFunction) *DocumentData {
+// oldTrees if given should be already edited with Tree.Edit, previous trees are closed
+func (s *Store) UpdateAndReloadDoc(id Id, content string, oldTrees *lsp.Trees, parse lsp.ParseFunction) *DocumentData {
 	// t := time.Now()
-	trees := parse(content, nil)
+	trees := parse(content, oldTrees)
+	if staleDoc, found := s.DocStore[id]; found {
+		staleDoc.Trees.Close()
+	}
 	doc := Document(content)
 	// utils.Sprintf("%dms<==parsing time", time.Since(t).Milliseconds())
 
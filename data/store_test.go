@@ -0,0 +1,63 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"sylmark/lsp"
+	"testing"
+
+	tree_sitter_markdown "github.com/sylveryte/tree-sitter-markdown/bindings/go"
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// parses like lsp handler, one parser per test
+func newTestParse(t *testing.T) lsp.ParseFunction {
+	parser := tree_sitter.NewParser()
+	parser.SetLanguage(tree_sitter.NewLanguage(tree_sitter_markdown.Language()))
+	inlineParser := tree_sitter.NewParser()
+	inlineParser.SetLanguage(tree_sitter.NewLanguage(tree_sitter_markdown.InlineLanguage()))
+	t.Cleanup(func() {
+		parser.Close()
+		inlineParser.Close()
+	})
+	return func(content string, oldTrees *lsp.Trees) *lsp.Trees {
+		var trees lsp.Trees
+		if oldTrees != nil {
+			trees[0] = parser.Parse([]byte(content), oldTrees.GetMainTree())
+			trees[1] = inlineParser.Parse([]byte(content), oldTrees.GetInlineTree())
+		} else {
+			trees[0] = parser.Parse([]byte(content), nil)
+			trees[1] = inlineParser.Parse([]byte(content), nil)
+		}
+		return &trees
+	}
+}
+
+// store with vault in a temp dir
+func newTestVault(t *testing.T) (*Store, lsp.ParseFunction) {
+	s := NewStore()
+	s.Config.RootPath = t.TempDir()
+	return &s, newTestParse(t)
+}
+
+func testNoteURI(s *Store, name string) lsp.DocumentURI {
+	return lsp.DocumentURI("file://" + filepath.Join(s.Config.RootPath, name))
+}
+
+// writes note to disk and loads it like vault loading does
+func addTestNote(t *testing.T, s *Store, parse lsp.ParseFunction, name string, content string) Id {
+	path := filepath.Join(s.Config.RootPath, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	id := s.GetIdFromURI(testNoteURI(s, name))
+	docData, ok := s.GetDocMustTree(id, parse)
+	if !ok {
+		t.Fatalf("Failed to read %s", name)
+	}
+	s.LoadData(id, string(docData.Content), docData.Trees)
+	return id
+}
@@ -1,21 +1,29 @@
 package data
 
 import (
+	"context"
 	"strings"
 	"sylmark/lsp"
 
 	"github.com/lithammer/fuzzysearch/fuzzy"
 )
 
-func (s *Store) GetAllSymbols(query string) (symbols []lsp.WorkspaceSymbol) {
+// stops early when ctx is done
+func (s *Store) GetAllSymbols(ctx context.Context, query string) (symbols []lsp.WorkspaceSymbol) {
 
 	if len(query) == 0 {
 		return
 	}
+	if strings.HasPrefix(query, "task:") {
+		return s.getTaskSymbols(query)
+	}
 	isFileOnly := query[0] == ' '
 	query = strings.TrimSpace(query)
 
 	for uri, id := range s.IdStore.uri {
+		if ctx.Err() != nil {
+			return
+		}
 		rootPath, err := s.GetPathRelRoot(uri)
 		if err != nil {
 			continue
@@ -67,21 +75,24 @@ func (s *Store) GetAllSymbols(query string) (symbols []lsp.WorkspaceSymbol) {
 		}
 	}
 
-	for t, refs := range s.Tags {
-		target := string(t)
-		match := fuzzy.MatchFold(query, target)
-		if match == false {
-			continue
-		}
-
-		for _, loc := range refs {
-			symbols = append(symbols, lsp.WorkspaceSymbol{
-				Name:     target,
-				Kind:     lsp.SymbolKindEnum,
-				Location: loc,
-			})
+	// walking the tree keeps nested tags after their parents
+	var addTagSymbols func(nodes []*TagNode, container string)
+	addTagSymbols = func(nodes []*TagNode, container string) {
+		for _, node := range nodes {
+			if fuzzy.MatchFold(query, string(node.Tag)) {
+				for _, loc := range s.Tags[node.Tag] {
+					symbols = append(symbols, lsp.WorkspaceSymbol{
+						Name:          string(node.Tag),
+						Kind:          lsp.SymbolKindEnum,
+						ContainerName: container,
+						Location:      loc,
+					})
+				}
+			}
+			addTagSymbols(node.Children, string(node.Tag))
 		}
 	}
+	addTagSymbols(s.GetTagTree(), "")
 
 	return
 }
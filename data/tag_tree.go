@@ -0,0 +1,92 @@
+package data
+
+import (
+	"slices"
+	"strings"
+)
+
+// nested tags, #project/alpha is child of #project even if #project itself is never used
+type TagNode struct {
+	Tag      Tag
+	Refs     int // of the tag only
+	Total    int // including nested tags
+	Children []*TagNode
+}
+
+// #project/alpha => #project
+func (t Tag) Parent() (Tag, bool) {
+	i := strings.LastIndex(string(t), "/")
+	if i < 2 {
+		return "", false
+	}
+	return t[:i], true
+}
+
+// #project/alpha/one => [#project/alpha #project]
+func (t Tag) Ancestors() (ancestors []Tag) {
+	parent, ok := t.Parent()
+	for ok {
+		ancestors = append(ancestors, parent)
+		parent, ok = parent.Parent()
+	}
+	return ancestors
+}
+
+// #project is ancestor of #project/alpha and #project/alpha/one
+func (t Tag) IsAncestorOf(tag Tag) bool {
+	return strings.HasPrefix(string(tag), string(t)+"/")
+}
+
+// references count of every tag including nested ones, has ancestors which are never used too
+func (s *Store) getTagTotals() map[Tag]int {
+	totals := map[Tag]int{}
+	for t, locs := range s.Tags {
+		totals[t] += len(locs)
+		for _, a := range t.Ancestors() {
+			totals[a] += len(locs)
+		}
+	}
+	return totals
+}
+
+// sorted used tags nested under tag
+func (s *Store) GetNestedTags(tag Tag) (tags []Tag) {
+	for t := range s.Tags {
+		if tag.IsAncestorOf(t) {
+			tags = append(tags, t)
+		}
+	}
+	slices.Sort(tags)
+	return tags
+}
+
+// root tags with nested tags as children, sorted by tag
+func (s *Store) GetTagTree() (roots []*TagNode) {
+	nodes := map[Tag]*TagNode{}
+	for t, total := range s.getTagTotals() {
+		nodes[t] = &TagNode{
+			Tag:   t,
+			Refs:  len(s.Tags[t]),
+			Total: total,
+		}
+	}
+	for t, node := range nodes {
+		parent, ok := t.Parent()
+		if ok {
+			nodes[parent].Children = append(nodes[parent].Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	for _, node := range nodes {
+		sortTagNodes(node.Children)
+	}
+	sortTagNodes(roots)
+	return roots
+}
+
+func sortTagNodes(nodes []*TagNode) {
+	slices.SortFunc(nodes, func(a, b *TagNode) int {
+		return strings.Compare(string(a.Tag), string(b.Tag))
+	})
+}
@@ -2,6 +2,8 @@ package data
 
 import (
 	"fmt"
+	"regexp"
+	"slices"
 	"strings"
 	"sylmark/lsp"
 
@@ -10,6 +12,17 @@ import (
 
 type Tag string
 
+var tagRegex = regexp.MustCompile(`^#[0-9]*[a-zA-Z_\-\/][a-zA-Z_\-\/0-9]*$`)
+
+// adds # if missing, ok if it's a valid tag
+func NewTag(name string) (Tag, bool) {
+	name = strings.TrimSpace(name)
+	if !strings.HasPrefix(name, "#") {
+		name = "#" + name
+	}
+	return Tag(name), tagRegex.MatchString(name)
+}
+
 func (s *Store) GetTagRefs(tag Tag) int {
 
 	clocs, found := s.Tags[tag]
@@ -20,23 +33,43 @@ func (s *Store) GetTagRefs(tag Tag) int {
 	return 0
 }
 
+// references of tag and all of it's nested tags
 func (s *Store) GetTagReferences(tag Tag) []lsp.Location {
-	return s.Tags[tag]
+	locs := append([]lsp.Location{}, s.Tags[tag]...)
+	for _, t := range s.GetNestedTags(tag) {
+		locs = append(locs, s.Tags[t]...)
+	}
+	return locs
 }
 
+// after #project/ only nested tags of #project are suggested
 func (s *Store) GetTagCompletions(arg string, rng lsp.Range) []lsp.CompletionItem {
 	completions := []lsp.CompletionItem{}
-	for t, v := range s.Tags {
+	var parent Tag
+	if i := strings.LastIndex(arg, "/"); i > 0 {
+		parent = Tag("#" + arg[:i])
+	}
+	for t, total := range s.getTagTotals() {
+		sortText := "a"
+		if len(parent) > 0 {
+			if !parent.IsAncestorOf(t) {
+				continue
+			}
+			if p, _ := t.Parent(); p != parent {
+				// grand children after children
+				sortText = "b"
+			}
+		}
 		completions = append(completions, lsp.CompletionItem{
 			Label:    string(t),
 			Kind:     lsp.EventCompletion,
-			SortText: fmt.Sprintf("%03d", 1000-len(v)),
+			SortText: fmt.Sprintf("%s%03d", sortText, 1000-total),
 			TextEdit: &lsp.TextEdit{
 				Range:   rng,
 				NewText: string(t),
 			},
 			Detail:        string(t),
-			Documentation: fmt.Sprintf("#%d refs", len(v)),
+			Documentation: fmt.Sprintf("#%d refs", total),
 		})
 	}
 
@@ -49,45 +82,56 @@ func (s *Store) AddTag(node *tree_sitter.Node, uri lsp.DocumentURI, content *str
 		return false
 	}
 
-	tag := GetTag(node, *content)
-	location := uri.LocationOf(node)
-	locations, found := s.Tags[tag]
-	if found {
-		s.Tags[tag] = append(locations, location)
-	} else {
-		s.Tags[tag] = []lsp.Location{location}
-	}
-
+	s.addTagLocation(GetTag(node, *content), uri.LocationOf(node))
 	return true
 }
 
+func (s *Store) addTagLocation(tag Tag, location lsp.Location) {
+	addLocation(s.Tags, tag, location)
+}
+
 // returns ok
 func (s *Store) RemoveTag(node *tree_sitter.Node, uri lsp.DocumentURI, content *string) bool {
 	if s == nil {
 		return false
 	}
 
-	tag := GetTag(node, *content)
-	loc := uri.LocationOf(node)
-	tagLocs, found := s.Tags[tag]
-	if found {
-		var newLocations []lsp.Location
+	s.removeTagLocation(GetTag(node, *content), uri.LocationOf(node))
+	return true
+}
 
-		for _, tagLoc := range tagLocs {
-			if tagLoc.URI == loc.URI && tagLoc.Range.Start == loc.Range.Start {
-				continue
-			}
-			newLocations = append(newLocations, tagLoc)
-		}
+func (s *Store) removeTagLocation(tag Tag, loc lsp.Location) {
+	removeLocation(s.Tags, tag, loc)
+}
 
-		if len(newLocations) == 0 {
-			delete(s.Tags, tag)
-		} else {
-			s.Tags[tag] = newLocations
+// indexes of where tags or front matter keys are used
+func addLocation[K comparable](index map[K][]lsp.Location, key K, location lsp.Location) {
+	index[key] = append(index[key], location)
+}
+
+func removeLocation[K comparable](index map[K][]lsp.Location, key K, loc lsp.Location) {
+	locs, found := index[key]
+	if !found {
+		return
+	}
+	var newLocations []lsp.Location
+	for _, l := range locs {
+		if l.URI == loc.URI && l.Range.Start == loc.Range.Start {
+			continue
 		}
+		newLocations = append(newLocations, l)
 	}
+	if len(newLocations) == 0 {
+		delete(index, key)
+	} else {
+		index[key] = newLocations
+	}
+}
 
-	return true
+func moveLocation[K comparable](index map[K][]lsp.Location, key K, loc lsp.Location, moved lsp.Location) {
+	if i := slices.Index(index[key], loc); i != -1 {
+		index[key][i] = moved
+	}
 }
 
 func GetTag(node *tree_sitter.Node, content string) Tag {
@@ -103,5 +147,49 @@ func (s *Store) GetTagHover(tag Tag) string {
 		return ""
 	}
 	totalRefs := s.GetTagRefs(tag)
-	return fmt.Sprintf("%d references of %s", totalRefs, tag)
+	hover := fmt.Sprintf("%d references of %s", totalRefs, tag)
+
+	nestedTags := s.GetNestedTags(tag)
+	if len(nestedTags) > 0 {
+		totals := s.getTagTotals()
+		hover += fmt.Sprintf(", %d including nested tags\n", totals[tag])
+		for _, t := range nestedTags {
+			hover += fmt.Sprintf("\n- %s %d", t, s.GetTagRefs(t))
+		}
+	}
+	return hover
+}
+
+// renames tag in every note, nested ones as #tag/child are renamed too if nested
+func (s *Store) GetTagRenameEdits(tag Tag, newName string, nested bool) (edit lsp.WorkspaceEdit, err error) {
+	newTag, ok := NewTag(newName)
+	if !ok {
+		return edit, fmt.Errorf("`%s` is not a valid tag", newName)
+	}
+	if _, found := s.Tags[tag]; !found && !nested {
+		return edit, fmt.Errorf("Tag `%s` not found", tag)
+	}
+
+	edit.Changes = map[lsp.DocumentURI][]lsp.TextEdit{}
+	for t, locs := range s.Tags {
+		var renamedTag Tag
+		if t == tag {
+			renamedTag = newTag
+		} else if nested && tag.IsAncestorOf(t) {
+			renamedTag = newTag + t[len(tag):]
+		} else {
+			continue
+		}
+		for _, loc := range locs {
+			newText := string(renamedTag)
+			if s.tagsWithoutHash[loc] {
+				newText = newText[1:]
+			}
+			edit.Changes[loc.URI] = append(edit.Changes[loc.URI], lsp.TextEdit{
+				Range:   loc.Range,
+				NewText: newText,
+			})
+		}
+	}
+	return edit, nil
 }
@@ -0,0 +1,161 @@
+package data
+
+import (
+	"maps"
+	"slices"
+	"sylmark/lsp"
+	"testing"
+)
+
+func TestTagHierarchy(t *testing.T) {
+	t.Run("1 Parent of nested tag", func(t *testing.T) {
+		parent, ok := Tag("#project/alpha").Parent()
+		if !ok || parent != "#project" {
+			t.Errorf("Parent >>> [#project] got [%s]", parent)
+		}
+	})
+	t.Run("2 No parent of plain tag", func(t *testing.T) {
+		parent, ok := Tag("#project").Parent()
+		if ok {
+			t.Errorf("Parent >>> [] got [%s]", parent)
+		}
+	})
+	t.Run("3 Ancestors of deep tag", func(t *testing.T) {
+		got := Tag("#project/alpha/one").Ancestors()
+		want := []Tag{"#project/alpha", "#project"}
+		if !slices.Equal(want, got) {
+			t.Errorf("Ancestors >>> %v got %v", want, got)
+		}
+	})
+	t.Run("4 Ancestor only on / boundary", func(t *testing.T) {
+		if !Tag("#project").IsAncestorOf("#project/alpha") {
+			t.Errorf("#project should be ancestor of #project/alpha")
+		}
+		if Tag("#project").IsAncestorOf("#projects") {
+			t.Errorf("#project should not be ancestor of #projects")
+		}
+	})
+
+	s := NewStore()
+	s.Tags["#project/alpha"] = []lsp.Location{{URI: "a"}, {URI: "b"}}
+	s.Tags["#project/alpha/one"] = []lsp.Location{{URI: "a"}}
+	s.Tags["#project/beta"] = []lsp.Location{{URI: "c"}}
+	s.Tags["#meeting"] = []lsp.Location{{URI: "c"}}
+
+	t.Run("5 Tree has unused parent as root", func(t *testing.T) {
+		roots := s.GetTagTree()
+		if len(roots) != 2 || roots[0].Tag != "#meeting" || roots[1].Tag != "#project" {
+			t.Fatalf("Roots >>> [#meeting #project] got %d roots", len(roots))
+		}
+		project := roots[1]
+		if project.Refs != 0 || project.Total != 4 {
+			t.Errorf("#project refs >>> [0/4] got [%d/%d]", project.Refs, project.Total)
+		}
+		if len(project.Children) != 2 || project.Children[0].Tag != "#project/alpha" {
+			t.Fatalf("#project children >>> [#project/alpha #project/beta] got %d", len(project.Children))
+		}
+		if project.Children[0].Total != 3 {
+			t.Errorf("#project/alpha total >>> [3] got [%d]", project.Children[0].Total)
+		}
+	})
+	t.Run("6 References roll up nested tags", func(t *testing.T) {
+		if got := len(s.GetTagReferences("#project")); got != 4 {
+			t.Errorf("References >>> [4] got [%d]", got)
+		}
+	})
+	t.Run("7 Completions after / are nested only", func(t *testing.T) {
+		completions := s.GetTagCompletions("project/", lsp.Range{})
+		var labels []string
+		for _, c := range completions {
+			labels = append(labels, c.Label)
+		}
+		slices.Sort(labels)
+		want := []string{"#project/alpha", "#project/alpha/one", "#project/beta"}
+		if !slices.Equal(want, labels) {
+			t.Errorf("Completions >>> %v got %v", want, labels)
+		}
+	})
+}
+
+func TestTagRenameFrontMatter(t *testing.T) {
+	s := NewStore()
+	uri := lsp.DocumentURI("file:///vault/a.md")
+	fm, _ := ParseFrontMatter("---\ntags: [project, '#project/alpha']\n---\nsee #project\n")
+	for _, e := range getFrontMatterEntries(fm) {
+		s.loadEntry(1, uri, e)
+	}
+	inline := lsp.Location{URI: uri, Range: lsp.Range{
+		Start: lsp.Position{Line: 3, Character: 4},
+		End:   lsp.Position{Line: 3, Character: 12},
+	}}
+	s.addTagLocation("#project", inline)
+
+	t.Run("1 # is kept as written", func(t *testing.T) {
+		edit, err := s.GetTagRenameEdits("#project", "work", true)
+		if err != nil {
+			t.Fatalf("GetTagRenameEdits failed %s", err)
+		}
+		got := map[int]string{}
+		for _, e := range edit.Changes[uri] {
+			got[e.Range.Start.Line*100+e.Range.Start.Character] = e.NewText
+		}
+		want := map[int]string{107: "work", 117: "#work/alpha", 304: "#work"}
+		if !maps.Equal(want, got) {
+			t.Errorf("Edits >>> %v got %v", want, got)
+		}
+	})
+	t.Run("2 Unloaded tag is forgotten", func(t *testing.T) {
+		for _, e := range getFrontMatterEntries(fm) {
+			s.unloadEntry(1, uri, e)
+		}
+		if len(s.tagsWithoutHash) != 0 {
+			t.Errorf("Tags without # >>> [] got %v", s.tagsWithoutHash)
+		}
+	})
+}
+
+func TestTagRenameEdits(t *testing.T) {
+	s := NewStore()
+	tags := []Tag{"#meeting", "#meetings", "#meetings/weekly", "#project/alpha", "#project/alpha/one", "#projects"}
+	for i, tag := range tags {
+		s.Tags[tag] = []lsp.Location{{URI: "file:///vault/a.md", Range: lsp.Range{
+			Start: lsp.Position{Line: i},
+			End:   lsp.Position{Line: i, Character: len(tag)},
+		}}}
+	}
+	tests := []struct {
+		name    string
+		tag     Tag
+		newName string
+		nested  bool
+		// new text by line of the tag
+		want map[int]string
+		err  bool
+	}{
+		{"1 Only the tag itself", "#meetings", "notes", false, map[int]string{1: "#notes"}, false},
+		{"2 Nested children too", "#meetings", "notes", true, map[int]string{1: "#notes", 2: "#notes/weekly"}, false},
+		{"3 Merge into used tag", "#meetings", "#meeting", true, map[int]string{1: "#meeting", 2: "#meeting/weekly"}, false},
+		{"4 Unused parent renames children", "#project", "work", true, map[int]string{3: "#work/alpha", 4: "#work/alpha/one"}, false},
+		{"5 Unused parent without nested", "#project", "work", false, nil, true},
+		{"6 Nested into nested", "#project/alpha", "#work/beta", true, map[int]string{3: "#work/beta", 4: "#work/beta/one"}, false},
+		{"7 Invalid new name", "#meeting", "two words", false, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edit, err := s.GetTagRenameEdits(tt.tag, tt.newName, tt.nested)
+			if (err != nil) != tt.err {
+				t.Fatalf("Error >>> %v got %v", tt.err, err)
+			}
+			var got map[int]string
+			for _, e := range edit.Changes["file:///vault/a.md"] {
+				if got == nil {
+					got = map[int]string{}
+				}
+				got[e.Range.Start.Line] = e.NewText
+			}
+			if !maps.Equal(tt.want, got) {
+				t.Errorf("Edits >>> %v got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package data
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sylmark/lsp"
+	"time"
+
+	"github.com/lithammer/fuzzysearch/fuzzy"
+	"github.com/tj/go-naturaldate"
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// - [ ] list item, comparable so that it can be loaded and unloaded as docEntry
+type Task struct {
+	// from checkbox till end of it's first line
+	Range   lsp.Range
+	Checked bool
+	Text    string
+	// heading above the task, empty before first heading
+	Heading SubTarget
+	// formatted as 2006-01-02
+	Due string
+}
+
+// task as sent to client by tasks.list
+type TaskItem struct {
+	Location lsp.Location `json:"location"`
+	Checked  bool         `json:"checked"`
+	Text     string       `json:"text"`
+	Note     string       `json:"note"`
+	Heading  string       `json:"heading,omitempty"`
+	Due      string       `json:"due,omitempty"`
+}
+
+var taskDueRegex = regexp.MustCompile(`(?:^|\s)due:\s*(\S+)`)
+var taskDateLinkRegex = regexp.MustCompile(`\[\[([^\[\]|#]+)`)
+
+// due: wins over date links, first date link otherwise
+func (s *Store) getTaskDue(text string) string {
+	layouts := []string{s.Config.DateLayout, time.DateOnly}
+	parse := func(value string) (string, bool) {
+		for _, layout := range layouts {
+			if date, err := time.Parse(layout, value); err == nil {
+				return date.Format(time.DateOnly), true
+			}
+		}
+		return "", false
+	}
+	if match := taskDueRegex.FindStringSubmatch(text); match != nil {
+		value := strings.Trim(match[1], "[]")
+		if due, ok := parse(value); ok {
+			return due
+		}
+	}
+	for _, match := range taskDateLinkRegex.FindAllStringSubmatch(text, -1) {
+		plain, _ := GetPlainTarget(Target(strings.TrimSpace(match[1])))
+		if due, ok := parse(string(plain)); ok {
+			return due
+		}
+	}
+	return ""
+}
+
+// tasks within span, heading is the one above span
+func (s *Store) getTasks(content string, trees *lsp.Trees, span lineSpan, heading SubTarget) (tasks []Task) {
+	lsp.TraverseNodeWithin(trees.GetMainTree().RootNode(), uint(span.start), uint(span.end), func(n *tree_sitter.Node) {
+		switch n.Kind() {
+		case "atx_heading":
+			if subTarget, ok := GetSubTarget(n, content); ok {
+				heading = subTarget
+			}
+		case "task_list_marker_checked", "task_list_marker_unchecked":
+			start := n.StartPosition()
+			line, _ := GetLineFromContent(content, int(start.Row))
+			line = strings.TrimRight(line, "\r")
+			if int(n.EndPosition().Column) > len(line) {
+				return
+			}
+			text := strings.TrimSpace(line[n.EndPosition().Column:])
+			tasks = append(tasks, Task{
+				Range: lsp.Range{
+					Start: lsp.Position{Line: int(start.Row), Character: int(start.Column)},
+					End:   lsp.Position{Line: int(start.Row), Character: len(line)},
+				},
+				Checked: n.Kind() == "task_list_marker_checked",
+				Text:    text,
+				Heading: heading,
+				Due:     s.getTaskDue(text),
+			})
+		}
+	})
+	return tasks
+}
+
+func (s *Store) addTask(id Id, task Task) {
+	s.Tasks[id] = append(s.Tasks[id], task)
+}
+
+func (s *Store) removeTask(id Id, task Task) {
+	tasks := s.Tasks[id]
+	i := slices.Index(tasks, task)
+	if i == -1 {
+		return
+	}
+	tasks = slices.Delete(tasks, i, i+1)
+	if len(tasks) == 0 {
+		delete(s.Tasks, id)
+	} else {
+		s.Tasks[id] = tasks
+	}
+}
+
+// open unless done or all is given, every other filter has to match as well
+type TaskFilter struct {
+	Open    bool
+	Done    bool
+	Overdue bool
+	// due on or before, 2006-01-02
+	DueBy string
+	Note  string
+	Text  []string
+}
+
+// args of tasks.list: open, done, all, overdue, due:<date>, note:<target>, anything else is searched in text
+func ParseTaskFilter(args []string, now time.Time) (filter TaskFilter, err error) {
+	filter.Open = true
+	for _, arg := range args {
+		arg = strings.TrimSpace(arg)
+		switch {
+		case len(arg) == 0:
+		case arg == "open":
+			filter.Open, filter.Done = true, false
+		case arg == "done":
+			filter.Open, filter.Done = false, true
+		case arg == "all":
+			filter.Open, filter.Done = true, true
+		case arg == "overdue":
+			filter.Overdue = true
+		case strings.HasPrefix(arg, "due:"):
+			value := strings.TrimPrefix(arg, "due:")
+			date, err := time.Parse(time.DateOnly, value)
+			if err != nil {
+				date, err = naturaldate.Parse(value, now, naturaldate.WithDirection(naturaldate.Future))
+				if err != nil {
+					return filter, fmt.Errorf("Invalid due date `%s`", value)
+				}
+			}
+			filter.DueBy = date.Format(time.DateOnly)
+		case strings.HasPrefix(arg, "note:"):
+			filter.Note = strings.ToLower(strings.TrimPrefix(arg, "note:"))
+		default:
+			filter.Text = append(filter.Text, strings.ToLower(arg))
+		}
+	}
+	return filter, nil
+}
+
+func isOverdue(task Task, today string) bool {
+	return !task.Checked && len(task.Due) > 0 && task.Due < today
+}
+
+func (f TaskFilter) matches(task Task, note string, today string) bool {
+	if (task.Checked && !f.Done) || (!task.Checked && !f.Open) {
+		return false
+	}
+	if f.Overdue && !isOverdue(task, today) {
+		return false
+	}
+	if len(f.DueBy) > 0 && (len(task.Due) == 0 || task.Due > f.DueBy) {
+		return false
+	}
+	if len(f.Note) > 0 && !strings.Contains(strings.ToLower(note), f.Note) {
+		return false
+	}
+	text := strings.ToLower(task.Text)
+	for _, t := range f.Text {
+		if !strings.Contains(text, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// tasks of every note, ones due first then by note and line
+func (s *Store) GetTaskItems(filter TaskFilter, now time.Time) []TaskItem {
+	today := now.Format(time.DateOnly)
+	items := []TaskItem{}
+	for id, tasks := range s.Tasks {
+		uri, ok := s.GetUri(id)
+		if !ok {
+			continue
+		}
+		note, ok := s.GetVaultTarget(uri)
+		if !ok {
+			continue
+		}
+		for _, task := range tasks {
+			if !filter.matches(task, string(note), today) {
+				continue
+			}
+			items = append(items, TaskItem{
+				Location: lsp.Location{URI: uri, Range: task.Range},
+				Checked:  task.Checked,
+				Text:     task.Text,
+				Note:     string(note),
+				Heading:  strings.TrimPrefix(string(task.Heading), "#"),
+				Due:      task.Due,
+			})
+		}
+	}
+	slices.SortFunc(items, func(a, b TaskItem) int {
+		if (len(a.Due) == 0) != (len(b.Due) == 0) {
+			return cmp.Compare(len(b.Due), len(a.Due))
+		}
+		return cmp.Or(
+			strings.Compare(a.Due, b.Due),
+			strings.Compare(a.Note, b.Note),
+			a.Location.Range.Start.Line-b.Location.Range.Start.Line,
+		)
+	})
+	return items
+}
+
+// flips checkbox of task on line
+func (s *Store) GetTaskToggleEdit(id Id, line int) (edit lsp.WorkspaceEdit, err error) {
+	i := slices.IndexFunc(s.Tasks[id], func(task Task) bool {
+		return task.Range.Start.Line == line
+	})
+	if i == -1 {
+		return edit, fmt.Errorf("No task found on line %d", line+1)
+	}
+	task := s.Tasks[id][i]
+	newText := "x"
+	if task.Checked {
+		newText = " "
+	}
+	// character within [ ]
+	rng := task.Range
+	rng.Start.Character++
+	rng.End = rng.Start
+	rng.End.Character++
+
+	uri, _ := s.GetUri(id)
+	edit.Changes = map[lsp.DocumentURI][]lsp.TextEdit{
+		uri: {{
+			Range:   rng,
+			NewText: newText,
+		}},
+	}
+	return edit, nil
+}
+
+// open tasks for queries starting with task:, they are left out of other queries
+func (s *Store) getTaskSymbols(query string) (symbols []lsp.WorkspaceSymbol) {
+	query = strings.TrimSpace(strings.TrimPrefix(query, "task:"))
+	for id, tasks := range s.Tasks {
+		uri, ok := s.GetUri(id)
+		if !ok {
+			continue
+		}
+		target, _ := GetTarget(uri)
+		for _, task := range tasks {
+			if task.Checked || !fuzzy.MatchFold(query, task.Text) {
+				continue
+			}
+			container := string(target)
+			if len(task.Heading) > 0 {
+				container += string(task.Heading)
+			}
+			symbols = append(symbols, lsp.WorkspaceSymbol{
+				Name:          "task: " + task.Text,
+				Kind:          lsp.SymbolKindEvent,
+				ContainerName: container,
+				Location:      lsp.Location{URI: uri, Range: task.Range},
+			})
+		}
+	}
+	return symbols
+}
+
+// open tasks of id due before today
+func (s *Store) getTaskDiagnostics(id Id, now time.Time) (items []lsp.Diagnostic) {
+	today := now.Format(time.DateOnly)
+	for _, task := range s.Tasks[id] {
+		if !isOverdue(task, today) {
+			continue
+		}
+		rng := task.Range
+		items = append(items, lsp.Diagnostic{
+			Range:    &rng,
+			Severity: lsp.DiagnosticSeverityWarning,
+			Message:  fmt.Sprintf("Task overdue since %s", task.Due),
+		})
+	}
+	return items
+}
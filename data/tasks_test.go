@@ -0,0 +1,152 @@
+package data
+
+import (
+	"slices"
+	"sylmark/lsp"
+	"testing"
+	"time"
+)
+
+func TestTaskDue(t *testing.T) {
+	s := NewStore()
+	t.Run("1 due: wins over date link", func(t *testing.T) {
+		if got := s.getTaskDue("call [[2025-01-03]] due: 2025-02-01"); got != "2025-02-01" {
+			t.Errorf("Due >>> [2025-02-01] got [%s]", got)
+		}
+	})
+	t.Run("2 First date link", func(t *testing.T) {
+		if got := s.getTaskDue("see [[Project]] on [[journal/2025-01-03|Friday]]"); got != "2025-01-03" {
+			t.Errorf("Due >>> [2025-01-03] got [%s]", got)
+		}
+	})
+	t.Run("3 No date", func(t *testing.T) {
+		if got := s.getTaskDue("due: someday [[Project]]"); got != "" {
+			t.Errorf("Due >>> [] got [%s]", got)
+		}
+	})
+}
+
+func TestTaskFilter(t *testing.T) {
+	now := time.Date(2025, 1, 10, 9, 0, 0, 0, time.UTC)
+	today := now.Format(time.DateOnly)
+	tasks := []Task{
+		{Text: "Write report", Due: "2025-01-05"},
+		{Text: "Buy milk"},
+		{Text: "Send invoice", Due: "2025-01-20"},
+		{Text: "Old one", Due: "2025-01-01", Checked: true},
+	}
+	getTexts := func(args ...string) (texts []string) {
+		filter, err := ParseTaskFilter(args, now)
+		if err != nil {
+			t.Fatalf("ParseTaskFilter %v failed %s", args, err)
+		}
+		for _, task := range tasks {
+			if filter.matches(task, "journal/2025-01-10", today) {
+				texts = append(texts, task.Text)
+			}
+		}
+		return texts
+	}
+
+	t.Run("1 Open by default", func(t *testing.T) {
+		want := []string{"Write report", "Buy milk", "Send invoice"}
+		if got := getTexts(); !slices.Equal(want, got) {
+			t.Errorf("Tasks >>> %v got %v", want, got)
+		}
+	})
+	t.Run("2 Overdue", func(t *testing.T) {
+		want := []string{"Write report"}
+		if got := getTexts("overdue"); !slices.Equal(want, got) {
+			t.Errorf("Tasks >>> %v got %v", want, got)
+		}
+	})
+	t.Run("3 Due by including done", func(t *testing.T) {
+		want := []string{"Write report", "Old one"}
+		if got := getTexts("all", "due:2025-01-10"); !slices.Equal(want, got) {
+			t.Errorf("Tasks >>> %v got %v", want, got)
+		}
+	})
+	t.Run("4 Text and note", func(t *testing.T) {
+		want := []string{"Buy milk"}
+		if got := getTexts("note:journal", "MILK"); !slices.Equal(want, got) {
+			t.Errorf("Tasks >>> %v got %v", want, got)
+		}
+		if got := getTexts("note:project"); len(got) != 0 {
+			t.Errorf("Tasks >>> [] got %v", got)
+		}
+	})
+}
+
+func TestTasks(t *testing.T) {
+	s, parse := newTestVault(t)
+	id := addTestNote(t, s, parse, "tasks.md", "- [ ] first\n\n# Work\n\n- [x] done it\n- [ ] call due: 2025-01-05\n\n## Home\n\n1. [ ] fix sink\n")
+	addTestNote(t, s, parse, "b.md", "- [ ] b early due: 2025-01-02\n- [ ] b plain\n- [x] paid due: 2025-01-01\n")
+	now := time.Date(2025, 1, 10, 9, 0, 0, 0, time.UTC)
+	rng := func(line, start, end int) lsp.Range {
+		return lsp.Range{Start: lsp.Position{Line: line, Character: start}, End: lsp.Position{Line: line, Character: end}}
+	}
+
+	t.Run("1 Tasks with heading above and markers", func(t *testing.T) {
+		want := []Task{
+			{Range: rng(0, 2, 11), Text: "first"},
+			{Range: rng(4, 2, 13), Checked: true, Text: "done it", Heading: "#Work"},
+			{Range: rng(5, 2, 26), Text: "call due: 2025-01-05", Heading: "#Work", Due: "2025-01-05"},
+			{Range: rng(9, 3, 15), Text: "fix sink", Heading: "#Home"},
+		}
+		if got := s.Tasks[id]; !slices.Equal(got, want) {
+			t.Errorf("Tasks >>> %v got %v", want, got)
+		}
+	})
+	t.Run("2 Toggle edit within brackets", func(t *testing.T) {
+		tests := []struct {
+			line    int
+			want    lsp.Range
+			newText string
+		}{
+			{4, rng(4, 3, 4), " "},
+			{9, rng(9, 4, 5), "x"},
+		}
+		for _, tt := range tests {
+			edit, err := s.GetTaskToggleEdit(id, tt.line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []lsp.TextEdit{{Range: tt.want, NewText: tt.newText}}
+			if got := edit.Changes[testNoteURI(s, "tasks.md")]; !slices.Equal(got, want) {
+				t.Errorf("Edits of line %d >>> %v got %v", tt.line, want, got)
+			}
+		}
+		if _, err := s.GetTaskToggleEdit(id, 1); err == nil {
+			t.Errorf("Line without task should fail")
+		}
+	})
+	t.Run("3 Items due first then by note and line", func(t *testing.T) {
+		filter, _ := ParseTaskFilter([]string{"all"}, now)
+		var got []string
+		for _, item := range s.GetTaskItems(filter, now) {
+			got = append(got, item.Note+" "+item.Heading+" "+item.Text)
+		}
+		want := []string{
+			"b  paid due: 2025-01-01",
+			"b  b early due: 2025-01-02",
+			"tasks Work call due: 2025-01-05",
+			"b  b plain",
+			"tasks  first",
+			"tasks Work done it",
+			"tasks Home fix sink",
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("Items >>> %v got %v", want, got)
+		}
+	})
+	t.Run("4 Diagnostics of open overdue tasks", func(t *testing.T) {
+		got := s.getTaskDiagnostics(id, now)
+		if len(got) != 1 || *got[0].Range != rng(5, 2, 26) || got[0].Message != "Task overdue since 2025-01-05" {
+			t.Errorf("Diagnostics >>> call at 5:2 got %v", got)
+		}
+		// paid is past due too but checked
+		if got := s.getTaskDiagnostics(s.GetIdFromURI(testNoteURI(s, "b.md")), now); len(got) != 1 {
+			t.Errorf("Diagnostics of b.md >>> 1 got %v", got)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package data
+
+import (
+	"maps"
+	"slices"
+	"sylmark/lsp"
+)
+
+// removes everything ids have added to stores except the file def, used when old content is unknown
+func (s *Store) sweepData(ids []Id) {
+	if len(ids) == 0 {
+		return
+	}
+	swept := map[Id]bool{}
+	uris := map[lsp.DocumentURI]bool{}
+	for _, id := range ids {
+		swept[id] = true
+		s.removeFrontMatterData(id)
+		delete(s.Tasks, id)
+		delete(s.docEntries, id)
+		if uri, ok := s.GetUri(id); ok {
+			uris[uri] = true
+		}
+		if link, found := s.LinkStore[id]; found {
+			for subTarget := range link.Def {
+				if len(subTarget) > 0 {
+					delete(link.Def, subTarget)
+				}
+			}
+		}
+	}
+	for linkId, link := range s.LinkStore {
+		for subTarget, refs := range link.Refs {
+			refs = slices.DeleteFunc(refs, func(loc IdLocation) bool {
+				return swept[loc.Id]
+			})
+			if len(refs) == 0 {
+				delete(link.Refs, subTarget)
+			} else {
+				link.Refs[subTarget] = refs
+			}
+		}
+		s.LinkStore[linkId] = link
+	}
+	sweepLocations(s.Tags, uris)
+	maps.DeleteFunc(s.tagsWithoutHash, func(loc lsp.Location, _ bool) bool {
+		return uris[loc.URI]
+	})
+	sweepLocations(s.FrontMatterKeys, uris)
+	for key, values := range s.FrontMatterValues {
+		sweepLocations(values, uris)
+		if len(values) == 0 {
+			delete(s.FrontMatterValues, key)
+		}
+	}
+}
+
+func sweepLocations[K comparable](index map[K][]lsp.Location, uris map[lsp.DocumentURI]bool) {
+	for key, locs := range index {
+		locs = slices.DeleteFunc(locs, func(loc lsp.Location) bool {
+			return uris[loc.URI]
+		})
+		if len(locs) == 0 {
+			delete(index, key)
+		} else {
+			index[key] = locs
+		}
+	}
+}
+
+// notes changed or created outside of editor are loaded again from disk, open ones are left to the client
+func (s *Store) ReloadChangedDocs(uris []lsp.DocumentURI, parse lsp.ParseFunction) {
+	var ids []Id
+	for _, uri := range uris {
+		if id := s.GetIdFromURI(uri); !s.IsDocOpen(id) {
+			ids = append(ids, id)
+		}
+	}
+	// sweeping removes every def and alias, unchanged notes shouldn't refresh what links to them
+	changedDefs := s.changedDefs
+	s.changedDefs = map[Id]map[SubTarget]bool{}
+	defs := map[Id]map[SubTarget]bool{}
+	aliases := map[Id][]Target{}
+	for _, id := range ids {
+		s.releaseDoc(id)
+		defs[id] = s.getDefSubTargets(id)
+		aliases[id] = s.GetAliases(id)
+	}
+	s.sweepData(ids)
+	for _, id := range ids {
+		docData, ok := s.GetDocMustTree(id, parse)
+		if ok {
+			s.LoadData(id, string(docData.Content), docData.Trees)
+		}
+		s.releaseDoc(id)
+	}
+	// marks of other ids, like shadows taking links of removed aliases, are kept
+	reloadChangedDefs := s.changedDefs
+	s.changedDefs = changedDefs
+	for id, subTargets := range reloadChangedDefs {
+		if _, reloaded := defs[id]; reloaded {
+			continue
+		}
+		for subTarget := range subTargets {
+			s.markDefChanged(id, subTarget)
+		}
+	}
+	for _, id := range ids {
+		s.markDefsChangedSince(id, defs[id])
+		if !slices.Equal(aliases[id], s.GetAliases(id)) {
+			s.markDefChanged(id, "")
+		}
+	}
+}
+
+// notes deleted outside of editor
+func (s *Store) RemoveDeletedDocs(uris []lsp.DocumentURI) {
+	var ids []Id
+	for _, uri := range uris {
+		if id, found := s.FindIdFromURI(uri); found {
+			ids = append(ids, id)
+		}
+	}
+	s.sweepData(ids)
+	for _, id := range ids {
+		s.RemoveDoc(id)
+	}
+}
+
+func (s *Store) AddOtherFile(path string) {
+	if !slices.Contains(s.OtherFiles, path) {
+		s.OtherFiles = append(s.OtherFiles, path)
+	}
+}
+
+func (s *Store) RemoveOtherFile(path string) {
+	s.OtherFiles = slices.DeleteFunc(s.OtherFiles, func(p string) bool {
+		return p == path
+	})
+}
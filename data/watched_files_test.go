@@ -0,0 +1,81 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sylmark/lsp"
+	"testing"
+)
+
+func TestWatchedFilesReload(t *testing.T) {
+	s, parse := newTestVault(t)
+	b := addTestNote(t, s, parse, "b.md", "# B\n\n## Part\n")
+	addTestNote(t, s, parse, "a.md", "[p](b.md#Part)\n")
+	// refs as "name line:character" sorted
+	refs := func(id Id, subTarget SubTarget) []string {
+		var got []string
+		for _, loc := range s.GetReferenceLocations(context.Background(), id, subTarget, parse) {
+			path, _ := s.GetPathRelRoot(loc.URI)
+			got = append(got, fmt.Sprintf("%s %d:%d", path, loc.Range.Start.Line, loc.Range.Start.Character))
+		}
+		slices.Sort(got)
+		return got
+	}
+	write := func(name string, content string) {
+		if err := os.WriteFile(filepath.Join(s.Config.RootPath, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("1 Changed note refs", func(t *testing.T) {
+		write("a.md", "text\n[b](b.md)\n")
+		s.ReloadChangedDocs([]lsp.DocumentURI{testNoteURI(s, "a.md")}, parse)
+		if got := refs(b, "#Part"); len(got) != 0 {
+			t.Errorf("Refs of #Part >>> [] got %v", got)
+		}
+		if got, want := refs(b, ""), []string{"a.md 1:0"}; !slices.Equal(got, want) {
+			t.Errorf("Refs >>> %v got %v", want, got)
+		}
+	})
+	t.Run("2 Changed note defs", func(t *testing.T) {
+		write("b.md", "# B\n\n## Renamed\n")
+		s.ReloadChangedDocs([]lsp.DocumentURI{testNoteURI(s, "b.md")}, parse)
+		defs := s.getDefSubTargets(b)
+		if defs["#Part"] || !defs["#Renamed"] {
+			t.Errorf("Defs >>> [#Renamed] got %v", defs)
+		}
+		if got, want := refs(b, ""), []string{"a.md 1:0"}; !slices.Equal(got, want) {
+			t.Errorf("Refs kept >>> %v got %v", want, got)
+		}
+	})
+	t.Run("3 Created note", func(t *testing.T) {
+		write("c.md", "[r](b.md#Renamed)\n")
+		s.ReloadChangedDocs([]lsp.DocumentURI{testNoteURI(s, "c.md")}, parse)
+		if got, want := refs(b, "#Renamed"), []string{"c.md 0:0"}; !slices.Equal(got, want) {
+			t.Errorf("Refs of #Renamed >>> %v got %v", want, got)
+		}
+	})
+	t.Run("4 Deleted note", func(t *testing.T) {
+		if err := os.Remove(filepath.Join(s.Config.RootPath, "c.md")); err != nil {
+			t.Fatal(err)
+		}
+		s.RemoveDeletedDocs([]lsp.DocumentURI{testNoteURI(s, "c.md")})
+		if got := refs(b, "#Renamed"); len(got) != 0 {
+			t.Errorf("Refs of #Renamed >>> [] got %v", got)
+		}
+		if _, found := s.DocStore[s.GetIdFromURI(testNoteURI(s, "c.md"))]; found {
+			t.Errorf("Doc of c.md should be removed")
+		}
+	})
+	t.Run("5 Open note is left to client", func(t *testing.T) {
+		s.OpenDoc(b, "# B\n\n## Renamed\n", parse)
+		write("b.md", "# B\n")
+		s.ReloadChangedDocs([]lsp.DocumentURI{testNoteURI(s, "b.md")}, parse)
+		if defs := s.getDefSubTargets(b); !defs["#Renamed"] {
+			t.Errorf("Defs >>> [#Renamed] got %v", defs)
+		}
+	})
+}
@@ -64,11 +64,16 @@ func (s *Store) GetWikiCompletions(arg string, needEnd bool, onlyFiles bool, rng
 	strppedArg := strings.TrimSpace(arg)
 	argContainsHash := strings.ContainsRune(arg, '#')
 	isWithin := (len(arg) > 0 && arg[0] == '#') || (len(strppedArg) > 0 && strppedArg[0] == '#')
+	// after #^ only block ids are offered, headings otherwise
+	wantsBlocks := strings.Contains(arg, "#^")
 	if isWithin {
 		doc, ok := s.GetDoc(id)
 		if ok && doc.Trees != nil {
 			headings := GetHeadings(&doc)
 			for _, target := range headings {
+				if IsBlockSubTarget(SubTarget(target)) != wantsBlocks {
+					continue
+				}
 				var link string
 				if needEnd {
 					link = "[[" + target + "]]"
@@ -86,7 +91,7 @@ func (s *Store) GetWikiCompletions(arg string, needEnd bool, onlyFiles bool, rng
 						Range:   rng,
 						NewText: link,
 					},
-					Detail: "", //syltodo excerpt
+					Data: s.getCompletionData(id, SubTarget(target)),
 				})
 			}
 		}
@@ -111,12 +116,13 @@ func (s *Store) GetWikiCompletions(arg string, needEnd bool, onlyFiles bool, rng
 				completions = append(completions, lsp.CompletionItem{
 					Label:    string(target),
 					Kind:     lsp.FileCompletion,
+					Detail:   s.getTargetDetail(target, ids),
 					SortText: "b",
 					TextEdit: &lsp.TextEdit{
 						Range:   rng,
 						NewText: link,
 					},
-					Detail: s.GetExcerpt(ids[0], lsp.Range{}),
+					Data: s.getCompletionData(ids[0], ""),
 				})
 			}
 			if onlyFiles {
@@ -126,6 +132,9 @@ func (s *Store) GetWikiCompletions(arg string, needEnd bool, onlyFiles bool, rng
 			for _, id := range ids {
 				subTargets := s.LinkStore.getSubTargetsAndRanges(id)
 				for _, subTargetNRange := range subTargets {
+					if IsBlockSubTarget(subTargetNRange.subTarget) != wantsBlocks {
+						continue
+					}
 					fullTarget := FullTarget(string(target) + string(subTargetNRange.subTarget))
 					match = fuzzy.MatchFold(arg, string(fullTarget))
 					if match {
@@ -138,10 +147,6 @@ func (s *Store) GetWikiCompletions(arg string, needEnd bool, onlyFiles bool, rng
 						} else {
 							link = fmt.Sprintf("[[%s", fullTarget)
 						}
-						var defRange lsp.Range
-						if subTargetNRange.rng != nil {
-							defRange = *subTargetNRange.rng
-						}
 						completions = append(completions, lsp.CompletionItem{
 							Label:    string(fullTarget),
 							Kind:     lsp.ReferenceCompletion,
@@ -150,7 +155,7 @@ func (s *Store) GetWikiCompletions(arg string, needEnd bool, onlyFiles bool, rng
 								Range:   rng,
 								NewText: link,
 							},
-							Detail: s.GetExcerpt(id, defRange),
+							Data: s.getCompletionData(id, subTargetNRange.subTarget),
 						})
 					}
 				}
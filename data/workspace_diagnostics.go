@@ -0,0 +1,149 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"slices"
+	"strings"
+	"sylmark/lsp"
+	"time"
+)
+
+type diagnosticLinksEntry struct {
+	hash  uint64
+	links []diagnosticLink
+	// depend only on content and config
+	frontMatter []lsp.Diagnostic
+	// of the file when it was read from disk, closed files not modified since are not read again
+	modTime int64
+	size    int64
+}
+
+func hashContent(content string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(content))
+	return h.Sum64()
+}
+
+// changes when either content or the diagnostics of it changes, other notes can resolve links
+func getDiagnosticsResultId(contentHash uint64, items []lsp.Diagnostic) string {
+	h := fnv.New64a()
+	b, _ := json.Marshal(items)
+	h.Write(b)
+	return fmt.Sprintf("%x-%x", contentHash, h.Sum64())
+}
+
+// diagnostics of open or loaded document and it's result id
+func (s *Store) GetDocumentDiagnostics(ctx context.Context, uri lsp.DocumentURI, parse lsp.ParseFunction) (items []lsp.Diagnostic, resultId string) {
+	items = s.GetDiagnostics(ctx, uri, parse)
+	var doc DocumentData
+	if id, found := s.FindIdFromURI(uri); found {
+		doc, _ = s.GetDoc(id)
+	}
+	return items, getDiagnosticsResultId(hashContent(string(doc.Content)), items)
+}
+
+// links of note, parses only when content has changed since last time
+func (s *Store) getCachedDiagnosticLinks(id Id, content string, parse lsp.ParseFunction) diagnosticLinksEntry {
+	hash := hashContent(content)
+	s.cacheMu.Lock()
+	entry, found := s.diagnosticLinks[id]
+	s.cacheMu.Unlock()
+	if found && entry.hash == hash {
+		return entry
+	}
+	trees := parse(content, nil)
+	entry = diagnosticLinksEntry{
+		hash:        hash,
+		links:       getDiagnosticLinks(content, trees),
+		frontMatter: s.getFrontMatterDiagnostics(content),
+	}
+	trees[0].Close()
+	trees[1].Close()
+	s.cacheMu.Lock()
+	s.diagnosticLinks[id] = entry
+	s.cacheMu.Unlock()
+	return entry
+}
+
+// same as getCachedDiagnosticLinks for notes on disk, skips reading ones whose modification time and size are same
+func (s *Store) getFileDiagnosticLinks(id Id, uri lsp.DocumentURI, parse lsp.ParseFunction) (diagnosticLinksEntry, bool) {
+	path, err := PathFromURI(uri)
+	if err != nil {
+		return diagnosticLinksEntry{}, false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return diagnosticLinksEntry{}, false
+	}
+	s.cacheMu.Lock()
+	entry, found := s.diagnosticLinks[id]
+	s.cacheMu.Unlock()
+	if found && entry.modTime == info.ModTime().UnixNano() && entry.size == info.Size() {
+		return entry, true
+	}
+	entry = s.getCachedDiagnosticLinks(id, ContentFromDocPath(path), parse)
+	entry.modTime = info.ModTime().UnixNano()
+	entry.size = info.Size()
+	s.cacheMu.Lock()
+	s.diagnosticLinks[id] = entry
+	s.cacheMu.Unlock()
+	return entry, true
+}
+
+// every loaded note, notes whose result id is same as previous one are reported unchanged, stops early when ctx is done
+func (s *Store) GetWorkspaceDiagnostics(ctx context.Context, previousResultIds []lsp.PreviousResultId, parse lsp.ParseFunction) lsp.WorkspaceDiagnosticReport {
+	previous := map[lsp.DocumentURI]string{}
+	for _, p := range previousResultIds {
+		uri, _ := CleanUpURI(string(p.URI))
+		previous[uri] = p.Value
+	}
+
+	report := lsp.WorkspaceDiagnosticReport{Items: []any{}}
+	uris := []lsp.DocumentURI{}
+	for uri, id := range s.IdStore.uri {
+		if _, isNote := s.LinkStore.GetDef(id, ""); isNote && IsMdFile(string(uri)) {
+			uris = append(uris, uri)
+		}
+	}
+	slices.SortFunc(uris, func(a, b lsp.DocumentURI) int {
+		return strings.Compare(string(a), string(b))
+	})
+
+	for _, uri := range uris {
+		if ctx.Err() != nil {
+			break
+		}
+		id := s.IdStore.uri[uri]
+		var entry diagnosticLinksEntry
+		if docData, found := s.DocStore[id]; found {
+			entry = s.getCachedDiagnosticLinks(id, string(docData.Content), parse)
+		} else if entry, found = s.getFileDiagnosticLinks(id, uri, parse); !found {
+			continue
+		}
+
+		items := s.getLinkDiagnostics(ctx, id, entry.links)
+		items = append(items, entry.frontMatter...)
+		items = append(items, s.getTaskDiagnostics(id, time.Now())...)
+		resultId := getDiagnosticsResultId(entry.hash, items)
+
+		if previous[uri] == resultId {
+			report.Items = append(report.Items, lsp.WorkspaceUnchangedDocumentDiagnosticReport{
+				Kind:     lsp.DiagnosticReportUnchanged,
+				ResultId: resultId,
+				URI:      uri,
+			})
+			continue
+		}
+		report.Items = append(report.Items, lsp.WorkspaceFullDocumentDiagnosticReport{
+			Kind:     lsp.DiagnosticReportFull,
+			ResultId: resultId,
+			URI:      uri,
+			Items:    items,
+		})
+	}
+	return report
+}
@@ -0,0 +1,96 @@
+package data
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sylmark/lsp"
+	"testing"
+	"time"
+)
+
+// result ids and whether reports are full, by uri
+func getWorkspaceReports(s *Store, previous map[lsp.DocumentURI]string, parse lsp.ParseFunction) (resultIds map[lsp.DocumentURI]string, full map[lsp.DocumentURI]int) {
+	var previousResultIds []lsp.PreviousResultId
+	for uri, value := range previous {
+		previousResultIds = append(previousResultIds, lsp.PreviousResultId{URI: uri, Value: value})
+	}
+	resultIds = map[lsp.DocumentURI]string{}
+	full = map[lsp.DocumentURI]int{}
+	for _, item := range s.GetWorkspaceDiagnostics(context.Background(), previousResultIds, parse).Items {
+		switch report := item.(type) {
+		case lsp.WorkspaceFullDocumentDiagnosticReport:
+			resultIds[report.URI] = report.ResultId
+			full[report.URI] = len(report.Items)
+		case lsp.WorkspaceUnchangedDocumentDiagnosticReport:
+			resultIds[report.URI] = report.ResultId
+		}
+	}
+	return resultIds, full
+}
+
+func TestWorkspaceDiagnostics(t *testing.T) {
+	s, parse := newTestVault(t)
+	a := addTestNote(t, s, parse, "a.md", "see [c](c.md)\n")
+	addTestNote(t, s, parse, "b.md", "# B\n")
+	aUri, bUri := testNoteURI(s, "a.md"), testNoteURI(s, "b.md")
+	s.releaseDoc(a)
+
+	resultIds, full := getWorkspaceReports(s, nil, parse)
+	t.Run("1 Full reports without previous result ids", func(t *testing.T) {
+		if len(resultIds) != 2 || len(full) != 2 {
+			t.Fatalf("Reports >>> 2 full got %v %v", resultIds, full)
+		}
+		if full[aUri] != 1 || full[bUri] != 0 {
+			t.Errorf("Diagnostics >>> a 1 b 0 got %v", full)
+		}
+	})
+	t.Run("2 Same result ids are reported unchanged", func(t *testing.T) {
+		again, full := getWorkspaceReports(s, resultIds, parse)
+		if len(full) != 0 {
+			t.Errorf("Full reports >>> [] got %v", full)
+		}
+		if again[aUri] != resultIds[aUri] || again[bUri] != resultIds[bUri] {
+			t.Errorf("Result ids >>> %v got %v", resultIds, again)
+		}
+	})
+	t.Run("3 Result id changes when link resolves", func(t *testing.T) {
+		addTestNote(t, s, parse, "c.md", "# C\n")
+		again, full := getWorkspaceReports(s, resultIds, parse)
+		if again[aUri] == resultIds[aUri] {
+			t.Errorf("Result id of a should change")
+		}
+		if n, found := full[aUri]; !found || n != 0 {
+			t.Errorf("Report of a >>> full without items got %v", full)
+		}
+		if _, found := full[bUri]; found {
+			t.Errorf("Report of b should be unchanged")
+		}
+		resultIds = again
+	})
+	t.Run("4 Closed file with same modification time and size isn't read", func(t *testing.T) {
+		path := filepath.Join(s.Config.RootPath, "a.md")
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// same size, link breaks again
+		if err := os.WriteFile(path, []byte("see [d](d.md)\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+			t.Fatal(err)
+		}
+		if again, _ := getWorkspaceReports(s, resultIds, parse); again[aUri] != resultIds[aUri] {
+			t.Errorf("Result id of a >>> %s got %s", resultIds[aUri], again[aUri])
+		}
+
+		modTime := info.ModTime().Add(time.Second)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		if _, full := getWorkspaceReports(s, resultIds, parse); full[aUri] != 1 {
+			t.Errorf("Modified a >>> full with 1 item got %v", full)
+		}
+	})
+}
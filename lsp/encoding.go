@@ -0,0 +1,73 @@
+package lsp
+
+import (
+	"slices"
+	"unicode/utf8"
+)
+
+// utf-8 is preferred when offered as it needs no conversion, utf-16 is what every client supports
+func NegotiatePositionEncoding(offered []PositionEncodingKind) PositionEncodingKind {
+	for _, encoding := range []PositionEncodingKind{PositionEncodingUTF8, PositionEncodingUTF32} {
+		if slices.Contains(offered, encoding) {
+			return encoding
+		}
+	}
+	return PositionEncodingUTF16
+}
+
+func runeWidth(r rune, encoding PositionEncodingKind) int {
+	switch encoding {
+	case PositionEncodingUTF32:
+		return 1
+	case PositionEncodingUTF8:
+		return utf8.RuneLen(r)
+	}
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// character of byte column col in line, columns past the line are counted as single units
+func CharacterFromByte(line string, col int, encoding PositionEncodingKind) int {
+	if encoding == PositionEncodingUTF8 {
+		return col
+	}
+	character := 0
+	i := 0
+	for i < col && i < len(line) {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		if r == utf8.RuneError && size == 1 {
+			character++
+		} else {
+			character += runeWidth(r, encoding)
+		}
+		i += size
+	}
+	if col > i {
+		character += col - i
+	}
+	return character
+}
+
+// byte column of character in line, a character within a surrogate pair points to the start of it's rune
+func ByteFromCharacter(line string, character int, encoding PositionEncodingKind) int {
+	if encoding == PositionEncodingUTF8 {
+		return character
+	}
+	units := 0
+	i := 0
+	for i < len(line) {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		width := 1
+		if r != utf8.RuneError || size != 1 {
+			width = runeWidth(r, encoding)
+		}
+		if units+width > character {
+			return i
+		}
+		units += width
+		i += size
+	}
+	return i + character - units
+}
@@ -0,0 +1,61 @@
+package lsp
+
+import "testing"
+
+func TestPositionEncoding(t *testing.T) {
+	// ग is 3 bytes and 1 utf-16 unit, 😀 is 4 bytes and 2 utf-16 units
+	line := "a ग 😀 [[note]]"
+	linkByte := len("a ग 😀 ")
+
+	t.Run("1 Negotiate prefers utf-8", func(t *testing.T) {
+		got := NegotiatePositionEncoding([]PositionEncodingKind{PositionEncodingUTF16, PositionEncodingUTF8})
+		if got != PositionEncodingUTF8 {
+			t.Errorf("Negotiate >>> [utf-8] got [%s]", got)
+		}
+	})
+	t.Run("2 Negotiate defaults to utf-16", func(t *testing.T) {
+		got := NegotiatePositionEncoding(nil)
+		if got != PositionEncodingUTF16 {
+			t.Errorf("Negotiate >>> [utf-16] got [%s]", got)
+		}
+	})
+	t.Run("3 Byte to utf-16 and back", func(t *testing.T) {
+		char := CharacterFromByte(line, linkByte, PositionEncodingUTF16)
+		if char != 7 {
+			t.Errorf("CharacterFromByte >>> [7] got [%d]", char)
+		}
+		col := ByteFromCharacter(line, char, PositionEncodingUTF16)
+		if col != linkByte {
+			t.Errorf("ByteFromCharacter >>> [%d] got [%d]", linkByte, col)
+		}
+	})
+	t.Run("4 Byte to utf-32 and back", func(t *testing.T) {
+		char := CharacterFromByte(line, linkByte, PositionEncodingUTF32)
+		if char != 6 {
+			t.Errorf("CharacterFromByte >>> [6] got [%d]", char)
+		}
+		col := ByteFromCharacter(line, char, PositionEncodingUTF32)
+		if col != linkByte {
+			t.Errorf("ByteFromCharacter >>> [%d] got [%d]", linkByte, col)
+		}
+	})
+	t.Run("5 utf-8 is unchanged", func(t *testing.T) {
+		if got := CharacterFromByte(line, linkByte, PositionEncodingUTF8); got != linkByte {
+			t.Errorf("CharacterFromByte >>> [%d] got [%d]", linkByte, got)
+		}
+	})
+	t.Run("6 Within surrogate pair points to start of rune", func(t *testing.T) {
+		emojiByte := len("a ग ")
+		if got := ByteFromCharacter(line, 5, PositionEncodingUTF16); got != emojiByte {
+			t.Errorf("ByteFromCharacter >>> [%d] got [%d]", emojiByte, got)
+		}
+	})
+	t.Run("7 Past end of line", func(t *testing.T) {
+		if got := CharacterFromByte("ग", 5, PositionEncodingUTF16); got != 3 {
+			t.Errorf("CharacterFromByte >>> [3] got [%d]", got)
+		}
+		if got := ByteFromCharacter("ग", 3, PositionEncodingUTF16); got != 5 {
+			t.Errorf("ByteFromCharacter >>> [5] got [%d]", got)
+		}
+	})
+}
@@ -46,10 +46,13 @@ type WorkspaceSymbolOptions struct {
 type ExecuteCommandOptions struct {
 	Commands []string `json:"commands"`
 }
+type SemanticTokensFullOptions struct {
+	Delta bool `json:"delta"`
+}
 type SemanticTokensOptions struct {
-	Legend SemanticTokensLegend `json:"legend"`
-	Range  bool                 `json:"range"`
-	Full   bool                 `json:"full"`
+	Legend SemanticTokensLegend      `json:"legend"`
+	Range  bool                      `json:"range"`
+	Full   SemanticTokensFullOptions `json:"full"`
 }
 
 type SemantiTokens struct {
@@ -57,6 +60,17 @@ type SemantiTokens struct {
 	Data     []uint `json:"data"`
 }
 
+type SemanticTokensEdit struct {
+	Start       uint   `json:"start"`
+	DeleteCount uint   `json:"deleteCount"`
+	Data        []uint `json:"data"`
+}
+
+type SemanticTokensDelta struct {
+	ResultId string               `json:"resultId"`
+	Edits    []SemanticTokensEdit `json:"edits"`
+}
+
 type InitializeOptions struct {
 	DocumentFormatting bool `json:"documentFormatting"`
 	RangeFormatting    bool `json:"documentRangeFormatting"`
@@ -66,7 +80,71 @@ type InitializeOptions struct {
 	Completion         bool `json:"completion"`
 }
 
-type ClientCapabilities struct{}
+type ClientCapabilities struct {
+	Workspace *WorkspaceClientCapabilities `json:"workspace,omitempty"`
+	General   *GeneralClientCapabilities   `json:"general,omitempty"`
+}
+
+type GeneralClientCapabilities struct {
+	PositionEncodings []PositionEncodingKind `json:"positionEncodings,omitempty"`
+}
+
+// error codes of responses defined by lsp on top of jsonrpc ones
+const (
+	CodeRequestCancelled int64 = -32800
+	CodeContentModified  int64 = -32801
+	CodeRequestFailed    int64 = -32803
+)
+
+type PositionEncodingKind string
+
+const (
+	PositionEncodingUTF8  PositionEncodingKind = "utf-8"
+	PositionEncodingUTF16 PositionEncodingKind = "utf-16"
+	PositionEncodingUTF32 PositionEncodingKind = "utf-32"
+)
+
+type WorkspaceClientCapabilities struct {
+	DidChangeWatchedFiles *DidChangeWatchedFilesClientCapabilities `json:"didChangeWatchedFiles,omitempty"`
+	Diagnostics           *DiagnosticWorkspaceClientCapabilities   `json:"diagnostics,omitempty"`
+}
+
+type DiagnosticWorkspaceClientCapabilities struct {
+	RefreshSupport bool `json:"refreshSupport"`
+}
+
+type DidChangeWatchedFilesClientCapabilities struct {
+	DynamicRegistration bool `json:"dynamicRegistration"`
+}
+
+type Registration struct {
+	Id              string `json:"id"`
+	Method          string `json:"method"`
+	RegisterOptions any    `json:"registerOptions,omitempty"`
+}
+
+type RegistrationParams struct {
+	Registrations []Registration `json:"registrations"`
+}
+
+type FileSystemWatcher struct {
+	GlobPattern string `json:"globPattern"`
+}
+
+type DidChangeWatchedFilesRegistrationOptions struct {
+	Watchers []FileSystemWatcher `json:"watchers"`
+}
+
+type FileChangeType int
+
+type FileEvent struct {
+	URI  DocumentURI    `json:"uri"`
+	Type FileChangeType `json:"type"`
+}
+
+type DidChangeWatchedFilesParams struct {
+	Changes []FileEvent `json:"changes"`
+}
 
 type InitializeResult struct {
 	Capabilities ServerCapabilities `json:"capabilities,omitempty"`
@@ -74,6 +152,16 @@ type InitializeResult struct {
 
 type TextDocumentSyncKind int
 
+type SaveOptions struct {
+	IncludeText bool `json:"includeText"`
+}
+
+type TextDocumentSyncOptions struct {
+	OpenClose bool                 `json:"openClose"`
+	Change    TextDocumentSyncKind `json:"change"`
+	Save      *SaveOptions         `json:"save,omitempty"`
+}
+
 const (
 	TDSKNone TextDocumentSyncKind = iota
 	TDSKFull
@@ -128,9 +216,10 @@ type RenameFilesParams struct {
 }
 
 type FileOperations struct {
-	DidDelete FileOperationRegistrationOptions `json:"didDelete"`
-	DidRename FileOperationRegistrationOptions `json:"didRename"`
-	DidCreate FileOperationRegistrationOptions `json:"didCreate"`
+	DidDelete  FileOperationRegistrationOptions `json:"didDelete"`
+	DidRename  FileOperationRegistrationOptions `json:"didRename"`
+	DidCreate  FileOperationRegistrationOptions `json:"didCreate"`
+	WillRename FileOperationRegistrationOptions `json:"willRename"`
 }
 
 type ServerCapabilitiesWorkspace struct {
@@ -142,8 +231,17 @@ type DiagnosticOptions struct {
 	WorkspaceDiagnostics  bool `json:"workspaceDiagnostics"`
 }
 
+type RenameOptions struct {
+	PrepareProvider bool `json:"prepareProvider"`
+}
+
 type ServerCapabilities struct {
-	TextDocumentSync           TextDocumentSyncKind        `json:"textDocumentSync,omitempty"`
+	PositionEncoding           PositionEncodingKind        `json:"positionEncoding,omitempty"`
+	TextDocumentSync           *TextDocumentSyncOptions    `json:"textDocumentSync,omitempty"`
+	FoldingRangeProvider       bool                        `json:"foldingRangeProvider,omitempty"`
+	DocumentLinkProvider       *DocumentLinkOptions        `json:"documentLinkProvider,omitempty"`
+	InlayHintProvider          bool                        `json:"inlayHintProvider,omitempty"`
+	CodeLensProvider           *CodeLensOptions            `json:"codeLensProvider,omitempty"`
 	DocumentSymbolProvider     bool                        `json:"documentSymbolProvider,omitempty"`
 	CompletionProvider         *CompletionProvider         `json:"completionProvider,omitempty"`
 	DefinitionProvider         bool                        `json:"definitionProvider,omitempty"`
@@ -157,6 +255,7 @@ type ServerCapabilities struct {
 	ExecuteCommandProvider     ExecuteCommandOptions       `json:"executeCommandProvider"`
 	Workspace                  ServerCapabilitiesWorkspace `json:"workspace,omitempty"`
 	WorkspaceSymbolProvider    WorkspaceSymbolOptions      `json:"workspaceSymbolProvider"`
+	RenameProvider             *RenameOptions              `json:"renameProvider,omitempty"`
 }
 
 type TextDocumentIdentifier struct {
@@ -170,6 +269,65 @@ type TextDocumentItem struct {
 	Text       string      `json:"text"`
 }
 
+type DocumentSymbolParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type FoldingRangeParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type FoldingRangeKind string
+
+type FoldingRange struct {
+	StartLine int              `json:"startLine"`
+	EndLine   int              `json:"endLine"`
+	Kind      FoldingRangeKind `json:"kind,omitempty"`
+}
+
+type DocumentLinkOptions struct {
+	ResolveProvider bool `json:"resolveProvider"`
+}
+
+type DocumentLinkParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type DocumentLink struct {
+	Range   Range                       `json:"range"`
+	Target  *DocumentURI                `json:"target,omitempty"`
+	Tooltip string                      `json:"tooltip,omitempty"`
+	Data    *TextDocumentPositionParams `json:"data,omitempty"`
+}
+
+type InlayHintParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+}
+
+type InlayHintKind int
+
+type InlayHint struct {
+	Position    Position      `json:"position"`
+	Label       string        `json:"label"`
+	Kind        InlayHintKind `json:"kind,omitempty"`
+	Tooltip     string        `json:"tooltip,omitempty"`
+	PaddingLeft bool          `json:"paddingLeft,omitempty"`
+}
+
+type CodeLensOptions struct {
+	ResolveProvider bool `json:"resolveProvider"`
+}
+
+type CodeLensParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type CodeLens struct {
+	Range   Range    `json:"range"`
+	Command *Command `json:"command,omitempty"`
+}
+
 type WorkspaceSymbolParams struct {
 	Query string `json:"query"`
 }
@@ -201,8 +359,9 @@ type DidCloseTextDocumentParams struct {
 	TextDocument TextDocumentIdentifier `json:"textDocument"`
 }
 
+// whole document is replaced when Range is nil
 type TextDocumentContentChangeEvent struct {
-	Range       Range  `json:"range"`
+	Range       *Range `json:"range,omitempty"`
 	RangeLength int    `json:"rangeLength"`
 	Text        string `json:"text"`
 }
@@ -217,8 +376,13 @@ type SemanticTokensParams struct {
 }
 
 type SemanticTokensRangeParams struct {
-	TextDocumentIdentifier
-	Range Range `json:"range"`
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+}
+
+type SemanticTokensDeltaParams struct {
+	TextDocument     TextDocumentIdentifier `json:"textDocument"`
+	PreviousResultId string                 `json:"previousResultId"`
 }
 
 type DidChangeTextDocumentParams struct {
@@ -243,6 +407,35 @@ type PublishDiagnosticsParams struct {
 type DocumentDiagnosticParams struct {
 	TextDocument TextDocumentIdentifier `json:"textDocument"`
 }
+
+type PreviousResultId struct {
+	URI   DocumentURI `json:"uri"`
+	Value string      `json:"value"`
+}
+
+type WorkspaceDiagnosticParams struct {
+	PreviousResultIds []PreviousResultId `json:"previousResultIds"`
+}
+
+// items are WorkspaceFullDocumentDiagnosticReport or WorkspaceUnchangedDocumentDiagnosticReport
+type WorkspaceDiagnosticReport struct {
+	Items []any `json:"items"`
+}
+
+type WorkspaceFullDocumentDiagnosticReport struct {
+	Kind     DiagnosticReportKind `json:"kind"`
+	ResultId string               `json:"resultId"`
+	URI      DocumentURI          `json:"uri"`
+	Version  *int                 `json:"version"`
+	Items    []Diagnostic         `json:"items"`
+}
+
+type WorkspaceUnchangedDocumentDiagnosticReport struct {
+	Kind     DiagnosticReportKind `json:"kind"`
+	ResultId string               `json:"resultId"`
+	URI      DocumentURI          `json:"uri"`
+	Version  *int                 `json:"version"`
+}
 type CodeActionContext struct {
 	Diagnostics []Diagnostic `json:"diagnostics"`
 }
@@ -279,6 +472,20 @@ type ReferencesParams struct {
 	TextDocumentPositionParams
 }
 
+type RenameParams struct {
+	TextDocumentPositionParams
+	NewName string `json:"newName"`
+}
+
+type PrepareRenameParams struct {
+	TextDocumentPositionParams
+}
+
+type PrepareRenameResult struct {
+	Range       Range  `json:"range"`
+	Placeholder string `json:"placeholder"`
+}
+
 type Location struct {
 	URI   DocumentURI `json:"uri"`
 	Range Range       `json:"range"`
@@ -301,8 +508,9 @@ type Hover struct {
 }
 
 type DiagnosticResult struct {
-	Kind  DiagnosticReportKind `json:"kind"`
-	Items []Diagnostic         `json:"items"`
+	Kind     DiagnosticReportKind `json:"kind"`
+	ResultId string               `json:"resultId,omitempty"`
+	Items    []Diagnostic         `json:"items"`
 }
 
 type Diagnostic struct {
@@ -331,6 +539,20 @@ type TextEdit struct {
 	NewText string `json:"newText"`
 }
 
+type WorkspaceEdit struct {
+	Changes map[DocumentURI][]TextEdit `json:"changes"`
+}
+
+type ApplyWorkspaceEditParams struct {
+	Label string        `json:"label,omitempty"`
+	Edit  WorkspaceEdit `json:"edit"`
+}
+
+type ApplyWorkspaceEditResult struct {
+	Applied       bool   `json:"applied"`
+	FailureReason string `json:"failureReason,omitempty"`
+}
+
 type Command struct {
 	Title     string `json:"title"`
 	Command   string `json:"command"`
@@ -343,7 +565,7 @@ type CompletionItem struct {
 	Kind                CompletionItemKind  `json:"kind,omitempty"`
 	Tags                []CompletionItemTag `json:"tags,omitempty"`
 	Detail              string              `json:"detail,omitempty"`
-	Documentation       string              `json:"documentation,omitempty"` // string | MarkupContent
+	Documentation       any                 `json:"documentation,omitempty"` // string | MarkupContent
 	Deprecated          bool                `json:"deprecated,omitempty"`
 	Preselect           bool                `json:"preselect,omitempty"`
 	SortText            string              `json:"sortText,omitempty"`
@@ -357,6 +579,13 @@ type CompletionItem struct {
 	Data                any                 `json:"data,omitempty"`
 }
 
+type MarkupKind string
+
+type MarkupContent struct {
+	Kind  MarkupKind `json:"kind"`
+	Value string     `json:"value"`
+}
+
 type SymbolKind int
 
 type WorkspaceSymbol struct {
@@ -366,6 +595,15 @@ type WorkspaceSymbol struct {
 	Location      Location   `json:"location"`
 }
 
+type DocumentSymbol struct {
+	Name           string           `json:"name"`
+	Detail         string           `json:"detail,omitempty"`
+	Kind           SymbolKind       `json:"kind"`
+	Range          Range            `json:"range"`
+	SelectionRange Range            `json:"selectionRange"`
+	Children       []DocumentSymbol `json:"children,omitempty"`
+}
+
 const (
 	SymbolKindFile          SymbolKind = 1
 	SymbolKindModule        SymbolKind = 2
@@ -412,6 +650,28 @@ const (
 	DiagnosticReportUnchanged DiagnosticReportKind = "unchanged"
 )
 
+const (
+	FoldingRangeKindComment FoldingRangeKind = "comment"
+	FoldingRangeKindImports FoldingRangeKind = "imports"
+	FoldingRangeKindRegion  FoldingRangeKind = "region"
+)
+
+const (
+	InlayHintKindType      InlayHintKind = 1
+	InlayHintKindParameter InlayHintKind = 2
+)
+
+const (
+	FileChangeTypeCreated FileChangeType = 1
+	FileChangeTypeChanged FileChangeType = 2
+	FileChangeTypeDeleted FileChangeType = 3
+)
+
+const (
+	MarkupKindPlainText MarkupKind = "plaintext"
+	MarkupKindMarkdown  MarkupKind = "markdown"
+)
+
 const (
 	MessageTypeError   MessageType = 1
 	MessageTypeWarning MessageType = 2
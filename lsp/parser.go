@@ -24,6 +24,18 @@ func (t *Trees) GetInlineTree() *tree_sitter.Tree {
 	return nil
 }
 
+// closes C allocated trees, safe on nil
+func (t *Trees) Close() {
+	if t == nil {
+		return
+	}
+	for _, tree := range t {
+		if tree != nil {
+			tree.Close()
+		}
+	}
+}
+
 type ParseFunction func(content string, oldTrees *Trees) *Trees
 
 func PrintTsTree(node tree_sitter.Node, depth int, cont string) {
@@ -75,6 +87,18 @@ func TraverseNodeWith(node *tree_sitter.Node, action func(*tree_sitter.Node)) {
 
 }
 
+// same as TraverseNodeWith, nodes outside of rows startRow to endRow are skipped along with their children
+func TraverseNodeWithin(node *tree_sitter.Node, startRow uint, endRow uint, action func(*tree_sitter.Node)) {
+	for i := range int(node.NamedChildCount()) {
+		child := node.NamedChild(uint(i))
+		if child.EndPosition().Row < startRow || child.StartPosition().Row > endRow {
+			continue
+		}
+		action(child)
+		TraverseNodeWithin(child, startRow, endRow, action)
+	}
+}
+
 func IsInlineParseNeeded(node *tree_sitter.Node) bool {
 	return node.Kind() == "inline" || node.Kind() == "paragraph"
 }
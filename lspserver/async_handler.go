@@ -0,0 +1,153 @@
+package lspserver
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sylmark/lsp"
+	"sync"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// requests which only answer from the store, they get their own goroutine and can be cancelled
+var asyncMethods = map[string]bool{
+	"textDocument/hover":                     true,
+	"textDocument/completion":                true,
+	"completionItem/resolve":                 true,
+	"textDocument/references":                true,
+	"textDocument/definition":                true,
+	"textDocument/semanticTokens/full":       true,
+	"textDocument/semanticTokens/full/delta": true,
+	"textDocument/semanticTokens/range":      true,
+	"textDocument/prepareRename":             true,
+	"textDocument/rename":                    true,
+	"textDocument/documentSymbol":            true,
+	"textDocument/foldingRange":              true,
+	"textDocument/documentLink":              true,
+	"documentLink/resolve":                   true,
+	"textDocument/inlayHint":                 true,
+	"textDocument/codeLens":                  true,
+	"textDocument/codeAction":                true,
+	"textDocument/diagnostic":                true,
+	"workspace/diagnostic":                   true,
+	"workspace/symbol":                       true,
+	"workspace/willRenameFiles":              true,
+}
+
+// notifications after which results of requests still running would be stale
+var contentChangeMethods = map[string]bool{
+	"textDocument/didChange":          true,
+	"workspace/didChangeWatchedFiles": true,
+	"workspace/didCreateFiles":        true,
+	"workspace/didDeleteFiles":        true,
+	"workspace/didRenameFiles":        true,
+}
+
+var (
+	errRequestCancelled = &jsonrpc2.Error{Code: lsp.CodeRequestCancelled, Message: "Request cancelled"}
+	errContentModified  = &jsonrpc2.Error{Code: lsp.CodeContentModified, Message: "Content modified"}
+)
+
+type cancelParams struct {
+	ID jsonrpc2.ID `json:"id"`
+}
+
+// document changes are handled in order on the read loop, read only requests run concurrently
+// with a context which is cancelled by $/cancelRequest or by a change of content
+type AsyncHandler struct {
+	handler *LangHandler
+
+	mu      sync.Mutex
+	pending map[jsonrpc2.ID]context.CancelCauseFunc
+}
+
+func NewAsyncHandler(handler *LangHandler) *AsyncHandler {
+	return &AsyncHandler{
+		handler: handler,
+		pending: map[jsonrpc2.ID]context.CancelCauseFunc{},
+	}
+}
+
+func (a *AsyncHandler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
+	if req.Method == "$/cancelRequest" {
+		var params cancelParams
+		if req.Params != nil && json.Unmarshal(*req.Params, &params) == nil {
+			a.cancel(params.ID, errRequestCancelled)
+		}
+		return
+	}
+
+	if !isReadOnly(req) {
+		if contentChangeMethods[req.Method] {
+			a.cancelAll(errContentModified)
+		}
+		result, err := a.handler.Handle(ctx, conn, req)
+		reply(ctx, conn, req, result, err)
+		return
+	}
+
+	reqCtx, cancel := context.WithCancelCause(ctx)
+	a.mu.Lock()
+	a.pending[req.ID] = cancel
+	a.mu.Unlock()
+	go func() {
+		defer func() {
+			a.mu.Lock()
+			delete(a.pending, req.ID)
+			a.mu.Unlock()
+			cancel(nil)
+		}()
+		result, err := a.handler.Handle(reqCtx, conn, req)
+		if reqCtx.Err() != nil {
+			result, err = nil, context.Cause(reqCtx)
+		}
+		reply(ctx, conn, req, result, err)
+	}()
+}
+
+// read only requests share the store, see LangHandler.Handle
+func isReadOnly(req *jsonrpc2.Request) bool {
+	return asyncMethods[req.Method] && !req.Notif
+}
+
+func (a *AsyncHandler) cancel(id jsonrpc2.ID, cause error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if cancel, found := a.pending[id]; found {
+		cancel(cause)
+	}
+}
+
+func (a *AsyncHandler) cancelAll(cause error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, cancel := range a.pending {
+		cancel(cause)
+	}
+}
+
+// same as jsonrpc2.HandlerWithError does
+func reply(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request, result any, err error) {
+	if req.Notif {
+		if err != nil {
+			slog.Error("notification " + req.Method + " failed " + err.Error())
+		}
+		return
+	}
+
+	resp := &jsonrpc2.Response{ID: req.ID}
+	if err == nil {
+		err = resp.SetResult(result)
+	}
+	if err != nil {
+		if e, ok := err.(*jsonrpc2.Error); ok {
+			resp.Error = e
+		} else {
+			resp.Error = &jsonrpc2.Error{Message: err.Error()}
+		}
+	}
+	if err := conn.SendResponse(ctx, resp); err != nil {
+		slog.Error("failed to send response for " + req.Method + " " + err.Error())
+	}
+}
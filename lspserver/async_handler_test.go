@@ -0,0 +1,110 @@
+package lspserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"slices"
+	"sylmark/lsp"
+	"testing"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// handler with grammars served over a pipe, client handles what server sends like window/showMessage
+func newTestConn(t *testing.T, client jsonrpc2.Handler) (*LangHandler, *jsonrpc2.Conn) {
+	h := NewHandler()
+	h.SetupGrammars()
+	h.Store.Config.RootPath = t.TempDir()
+	serverPipe, clientPipe := net.Pipe()
+	ctx := context.Background()
+	h.Connection = jsonrpc2.NewConn(ctx, jsonrpc2.NewBufferedStream(serverPipe, jsonrpc2.VSCodeObjectCodec{}), NewAsyncHandler(h))
+	if client == nil {
+		client = jsonrpc2.HandlerWithError(func(context.Context, *jsonrpc2.Conn, *jsonrpc2.Request) (any, error) {
+			return nil, nil
+		})
+	}
+	conn := jsonrpc2.NewConn(ctx, jsonrpc2.NewBufferedStream(clientPipe, jsonrpc2.VSCodeObjectCodec{}), client)
+	t.Cleanup(func() {
+		conn.Close()
+		h.Connection.Close()
+		h.Parser.Close()
+		h.InlineParser.Close()
+	})
+	return h, conn
+}
+
+// read request sent while a write holds the store, it waits in it's goroutine
+func dispatchQueuedRead(t *testing.T, h *LangHandler, conn *jsonrpc2.Conn, id uint64) jsonrpc2.Waiter {
+	uri := lsp.DocumentURI("file://" + filepath.Join(h.Store.Config.RootPath, "a.md"))
+	call, err := conn.DispatchCall(context.Background(), "textDocument/documentSymbol",
+		lsp.DocumentSymbolParams{TextDocument: lsp.TextDocumentIdentifier{URI: uri}}, jsonrpc2.PickID(jsonrpc2.ID{Num: id}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return call
+}
+
+func getErrorCode(err error) int64 {
+	var rpcErr *jsonrpc2.Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code
+	}
+	return 0
+}
+
+func TestAsyncHandler(t *testing.T) {
+	t.Run("1 Cancelled queued read", func(t *testing.T) {
+		h, conn := newTestConn(t, nil)
+		h.Store.Lock()
+		call := dispatchQueuedRead(t, h, conn, 1)
+		if err := conn.Notify(context.Background(), "$/cancelRequest", cancelParams{ID: jsonrpc2.ID{Num: 1}}); err != nil {
+			t.Fatal(err)
+		}
+		// pipe has no buffer, the write after it is read only once the cancel is handled
+		if err := conn.Notify(context.Background(), "initialized", nil); err != nil {
+			t.Fatal(err)
+		}
+		h.Store.Unlock()
+		if err := call.Wait(context.Background(), nil); getErrorCode(err) != lsp.CodeRequestCancelled {
+			t.Errorf("Error >>> %d got %v", lsp.CodeRequestCancelled, err)
+		}
+	})
+	t.Run("2 Writes in order and read after them sees all", func(t *testing.T) {
+		h, conn := newTestConn(t, nil)
+		ctx := context.Background()
+		uri := lsp.DocumentURI("file://" + filepath.Join(h.Store.Config.RootPath, "a.md"))
+		if err := conn.Notify(ctx, "textDocument/didOpen", lsp.DidOpenTextDocumentParams{
+			TextDocument: lsp.TextDocumentItem{URI: uri, Text: "# h0\n"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		// each change appends at end of the one before, out of order they would land mid note
+		for i := 1; i < 10; i++ {
+			end := lsp.Position{Line: i}
+			if err := conn.Notify(ctx, "textDocument/didChange", lsp.DidChangeTextDocumentParams{
+				TextDocument: lsp.VersionedTextDocumentIdentifier{TextDocumentIdentifier: lsp.TextDocumentIdentifier{URI: uri}, Version: i},
+				ContentChanges: []lsp.TextDocumentContentChangeEvent{{
+					Range: &lsp.Range{Start: end, End: end},
+					Text:  fmt.Sprintf("# h%d\n", i),
+				}},
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var symbols []lsp.DocumentSymbol
+		if err := conn.Call(ctx, "textDocument/documentSymbol", lsp.DocumentSymbolParams{TextDocument: lsp.TextDocumentIdentifier{URI: uri}}, &symbols); err != nil {
+			t.Fatal(err)
+		}
+		var got, want []string
+		for i, symbol := range symbols {
+			got = append(got, symbol.Name)
+			want = append(want, fmt.Sprintf("h%d", i))
+		}
+		if len(got) != 10 || !slices.Equal(got, want) {
+			t.Errorf("Headings >>> h0 to h9 got %v", got)
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package lspserver
+
+import (
+	"context"
+	"fmt"
+	"sylmark/lsp"
+)
+
+func (h *LangHandler) RegisterWatchedFiles() error {
+	params := lsp.RegistrationParams{
+		Registrations: []lsp.Registration{
+			{
+				Id:     "sylmark-watched-files",
+				Method: "workspace/didChangeWatchedFiles",
+				RegisterOptions: lsp.DidChangeWatchedFilesRegistrationOptions{
+					Watchers: []lsp.FileSystemWatcher{
+						{GlobPattern: "**/*.md"},
+						{GlobPattern: "**/*.{png,jpg,jpeg,gif,webp,avif,svg,pdf}"},
+					},
+				},
+			},
+		},
+	}
+	err := h.Connection.Call(context.Background(), "client/registerCapability", params, nil)
+	if err != nil {
+		return fmt.Errorf("failed to call client/registerCapability: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package lspserver
+
+import (
+	"context"
+	"encoding/json"
+	"sylmark/data"
+	"sylmark/lsp"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+func (h *LangHandler) handleCompletionItemResolve(ctx context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var item lsp.CompletionItem
+	if err := json.Unmarshal(*req.Params, &item); err != nil {
+		return nil, err
+	}
+	var withData struct {
+		Data *data.CompletionData `json:"data"`
+	}
+	if err := json.Unmarshal(*req.Params, &withData); err != nil || withData.Data == nil {
+		return item, nil
+	}
+
+	return h.Store.ResolveCompletionItem(ctx, item, *withData.Data, h.parse), nil
+}
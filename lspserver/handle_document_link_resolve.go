@@ -0,0 +1,23 @@
+package lspserver
+
+import (
+	"context"
+	"encoding/json"
+	"sylmark/lsp"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+func (h *LangHandler) handleDocumentLinkResolve(ctx context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var link lsp.DocumentLink
+	if err := json.Unmarshal(*req.Params, &link); err != nil {
+		return nil, err
+	}
+
+	return h.Store.ResolveDocumentLink(ctx, link, h.parse), nil
+}
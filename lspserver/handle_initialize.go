@@ -19,6 +19,11 @@ func (h *LangHandler) handleInitialize(_ context.Context, _ *jsonrpc2.Conn, req
 		return nil, err
 	}
 
+	h.ClientCapabilities = params.Capabilities
+	if params.Capabilities.General != nil {
+		h.Store.PositionEncoding = lsp.NegotiatePositionEncoding(params.Capabilities.General.PositionEncodings)
+	}
+
 	// The rootUri of the workspace. Is null if no folder is open or no rootmakers added
 	if params.RootURI != "" {
 		rootPath, err := data.DirPathFromURI(params.RootURI)
@@ -37,39 +42,59 @@ func (h *LangHandler) handleInitialize(_ context.Context, _ *jsonrpc2.Conn, req
 	}
 	return lsp.InitializeResult{
 		Capabilities: lsp.ServerCapabilities{
+			PositionEncoding: h.Store.PositionEncoding,
 			HoverProvider:    true,
-			TextDocumentSync: lsp.TDSKFull,
+			TextDocumentSync: &lsp.TextDocumentSyncOptions{
+				OpenClose: true,
+				Change:    lsp.TDSKIncremental,
+				Save: &lsp.SaveOptions{
+					IncludeText: true,
+				},
+			},
 			CompletionProvider: &lsp.CompletionProvider{
 				ResolveProvider:   true,
 				TriggerCharacters: []string{"[[", "|", "#"},
 			},
 			Workspace: lsp.ServerCapabilitiesWorkspace{
 				FileOperations: lsp.FileOperations{
-					DidDelete: fileOperationRegistrationOptions,
-					DidRename: fileOperationRegistrationOptions,
-					DidCreate: fileOperationRegistrationOptions,
+					DidDelete:  fileOperationRegistrationOptions,
+					DidRename:  fileOperationRegistrationOptions,
+					DidCreate:  fileOperationRegistrationOptions,
+					WillRename: fileOperationRegistrationOptions,
 				},
 			},
 			WorkspaceSymbolProvider: lsp.WorkspaceSymbolOptions{
 				ResolveProvider: true,
 			},
-			DefinitionProvider: true,
-			ReferencesProvider: true,
+			DefinitionProvider:     true,
+			DocumentSymbolProvider: true,
+			InlayHintProvider:      true,
+			CodeLensProvider:       &lsp.CodeLensOptions{},
+			DocumentLinkProvider: &lsp.DocumentLinkOptions{
+				ResolveProvider: true,
+			},
+			FoldingRangeProvider: true,
+			ReferencesProvider:   true,
 			DiagnosticProvider: lsp.DiagnosticOptions{
 				InterFileDependencies: true,
-				WorkspaceDiagnostics:  false,
+				WorkspaceDiagnostics:  true,
 			},
 			CodeActionProvider: true,
+			RenameProvider: &lsp.RenameOptions{
+				PrepareProvider: true,
+			},
 			ExecuteCommandProvider: lsp.ExecuteCommandOptions{
-				Commands: []string{"show", "graph"},
+				Commands: []string{"show", "graph", "mergeTags", "expandEmbed", "tasks.list", "tasks.toggle"},
 			},
 			SemanticTokensProvider: lsp.SemanticTokensOptions{
 				Legend: lsp.SemanticTokensLegend{
-					TokenTypes:     []lsp.SemanticTokenType{lsp.ClassSematicTokenType},
-					TokenModifiers: []lsp.SemanticTokenModifier{},
+					TokenTypes:     data.SemanticTokenTypes,
+					TokenModifiers: data.SemanticTokenModifiers,
+				},
+				Full: lsp.SemanticTokensFullOptions{
+					Delta: true,
 				},
-				Full:  true,
-				Range: false,
+				Range: true,
 			},
 		},
 	}, nil
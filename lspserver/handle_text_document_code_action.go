@@ -3,13 +3,14 @@ package lspserver
 import (
 	"context"
 	"encoding/json"
+	"strconv"
 	"sylmark/data"
 	"sylmark/lsp"
 
 	"github.com/sourcegraph/jsonrpc2"
 )
 
-func (h *LangHandler) handleCodeAction(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+func (h *LangHandler) handleCodeAction(ctx context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
 
 	if req.Params == nil {
 		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
@@ -20,9 +21,25 @@ func (h *LangHandler) handleCodeAction(_ context.Context, _ *jsonrpc2.Conn, req
 		return nil, err
 	}
 	params.TextDocument.URI, _ = data.CleanUpURI(string(params.TextDocument.URI))
+	// command gets position as client sent it
+	clientStart := params.Range.Start
+	params.Range = h.decodeRange(params.TextDocument.URI, params.Range)
 
-	id := h.Store.GetIdFromURI(params.TextDocument.URI)
-	items := h.Store.GetCodeActions(id, params.Context.Diagnostics, params.Range, h.parse)
+	id, found := h.Store.FindIdFromURI(params.TextDocument.URI)
+	if !found {
+		return nil, nil
+	}
+	items := h.Store.GetCodeActions(ctx, id, params.Context.Diagnostics, params.Range, h.parse)
+	if _, _, _, ok := h.Store.GetEmbedAt(id, params.Range.Start, h.parse); ok {
+		items = append(items, lsp.CodeAction{
+			Title: "Expand embed",
+			Command: lsp.Command{
+				Title:     "Expand embed",
+				Command:   "expandEmbed",
+				Arguments: []any{params.TextDocument.URI, strconv.Itoa(clientStart.Line), strconv.Itoa(clientStart.Character)},
+			},
+		})
+	}
 
 	return items, nil
 }
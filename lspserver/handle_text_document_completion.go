@@ -21,6 +21,7 @@ func (h *LangHandler) handleTextDocumentCompletion(_ context.Context, _ *jsonrpc
 	}
 
 	params.TextDocument.URI, _ = data.CleanUpURI(string(params.TextDocument.URI))
+	params.Position = h.decodePosition(params.TextDocument.URI, params.Position)
 
 	locs, ok := h.Store.GetCompletions(params)
 	return locs, ok
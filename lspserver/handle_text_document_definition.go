@@ -10,7 +10,7 @@ import (
 	"github.com/sourcegraph/jsonrpc2"
 )
 
-func (h *LangHandler) handleTextDocumentDefinition(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+func (h *LangHandler) handleTextDocumentDefinition(ctx context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
 
 	if req.Params == nil {
 		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
@@ -21,8 +21,18 @@ func (h *LangHandler) handleTextDocumentDefinition(_ context.Context, _ *jsonrpc
 		return nil, err
 	}
 	params.TextDocument.URI, _ = data.CleanUpURI(string(params.TextDocument.URI))
+	params.Position = h.decodePosition(params.TextDocument.URI, params.Position)
 
-	id := h.Store.GetIdFromURI(params.TextDocument.URI)
+	id, found := h.Store.FindIdFromURI(params.TextDocument.URI)
+	if !found {
+		return nil, nil
+	}
+	if target, subTarget, _, ok := h.Store.GetEmbedAt(id, params.Position, h.parse); ok {
+		defs := h.Store.GetEmbedDefs(ctx, id, target, subTarget)
+		locs := []lsp.Location{}
+		locs = *h.Store.FillInLocations(&locs, &defs)
+		return locs, nil
+	}
 	doc, node, ok := h.DocAndNodeFromURIAndPosition(id, params.Position, h.parse)
 	if !ok {
 		return nil, nil
@@ -85,7 +95,7 @@ func (h *LangHandler) handleTextDocumentDefinition(_ context.Context, _ *jsonrpc
 						// is id even proper?? for subtarget??
 						// check others refs hover
 						locs := []lsp.Location{}
-						defs, found := h.Store.GetDefsFromTarget(target, subTarget)
+						defs, found := h.Store.GetDefsFromTarget(ctx, target, subTarget)
 
 						if !found {
 							// file doesn't exists create uri and open it
@@ -9,7 +9,7 @@ import (
 	"github.com/sourcegraph/jsonrpc2"
 )
 
-func (h *LangHandler) handleDiagnostics(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+func (h *LangHandler) handleDiagnostics(ctx context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
 
 	if req.Params == nil {
 		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
@@ -21,11 +21,12 @@ func (h *LangHandler) handleDiagnostics(_ context.Context, _ *jsonrpc2.Conn, req
 	}
 	params.TextDocument.URI, _ = data.CleanUpURI(string(params.TextDocument.URI))
 
-	items := h.Store.GetDiagnostics(params.TextDocument.URI, h.parse)
+	items, resultId := h.Store.GetDocumentDiagnostics(ctx, params.TextDocument.URI, h.parse)
 
 	result = lsp.DiagnosticResult{
-		Kind:  lsp.DiagnosticReportFull,
-		Items: items,
+		Kind:     lsp.DiagnosticReportFull,
+		ResultId: resultId,
+		Items:    items,
 	}
 
 	return result, nil
@@ -10,7 +10,7 @@ import (
 	"github.com/sourcegraph/jsonrpc2"
 )
 
-func (h *LangHandler) handleHover(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+func (h *LangHandler) handleHover(ctx context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
 
 	if req.Params == nil {
 		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
@@ -21,7 +21,50 @@ func (h *LangHandler) handleHover(_ context.Context, _ *jsonrpc2.Conn, req *json
 		return nil, err
 	}
 	params.TextDocument.URI, _ = data.CleanUpURI(string(params.TextDocument.URI))
-	id := h.Store.GetIdFromURI(params.TextDocument.URI)
+	params.Position = h.decodePosition(params.TextDocument.URI, params.Position)
+	id, found := h.Store.FindIdFromURI(params.TextDocument.URI)
+	if !found {
+		return nil, nil
+	}
+
+	if content, rng, ok := h.Store.GetFrontMatterHover(id, params.Position); ok {
+		return lsp.Hover{
+			Contents: content,
+			Range:    &rng,
+		}, nil
+	}
+
+	if subTarget, rng, ok := h.Store.GetBlockIdAt(id, params.Position); ok {
+		refs := h.Store.GetReferenceLocations(ctx, id, subTarget, h.parse)
+		return lsp.Hover{
+			Contents: fmt.Sprintf("%d references found\n", len(refs)),
+			Range:    &rng,
+		}, nil
+	}
+
+	if target, subTarget, rng, ok := h.Store.GetEmbedAt(id, params.Position, h.parse); ok {
+		var content string
+		defs := h.Store.GetEmbedDefs(ctx, id, target, subTarget)
+		if len(defs) > 1 {
+			content = fmt.Sprintf("%d definitions found\n", len(defs))
+		}
+		for _, def := range defs {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			content += h.Store.LinkStore.GetSubTargetHover(def.Id, subTarget) + "\n---\n"
+			if embedded, ok := h.Store.GetEmbedContent(def.Id, subTarget, h.parse); ok {
+				content += embedded + "\n"
+			}
+		}
+		if len(content) == 0 {
+			return nil, nil
+		}
+		return lsp.Hover{
+			Contents: content,
+			Range:    &rng,
+		}, nil
+	}
 
 	doc, node, ok := h.DocAndNodeFromURIAndPosition(id, params.Position, h.parse)
 	if !ok {
@@ -87,13 +130,16 @@ func (h *LangHandler) handleHover(_ context.Context, _ *jsonrpc2.Conn, req *json
 				target, subTarget, _, ok := data.GetWikilinkTargets(parentedNode, string(doc.Content))
 				// utils.Sprintf("Idhar tak %s %s", target, subTarget)
 				if ok {
-					liddefs, defFound := h.Store.GetDefsFromTarget(target, subTarget)
+					liddefs, defFound := h.Store.GetDefsFromTarget(ctx, target, subTarget)
 					// utils.Sprintf("liddefs=%d", len(liddefs))
 					if defFound {
 						if len(liddefs) > 1 {
 							content = fmt.Sprintf("%d definitions found\n", len(liddefs))
 						}
 						for _, ldef := range liddefs {
+							if err := ctx.Err(); err != nil {
+								return nil, err
+							}
 							content += h.Store.LinkStore.GetSubTargetHover(ldef.Id, subTarget) + "\n---"
 							content += h.Store.GetExcerpt(ldef.Id, ldef.Range)
 						}
@@ -105,9 +151,19 @@ func (h *LangHandler) handleHover(_ context.Context, _ *jsonrpc2.Conn, req *json
 		{
 			target, _ := data.GetTarget(params.TextDocument.URI)
 			content += fmt.Sprintf("File Details: `%s`\n---\n", target)
+			if title, ok := h.Store.GetTitle(id); ok {
+				content += fmt.Sprintf("Title: %s\n", title)
+			}
+			if aliases := h.Store.GetAliases(id); len(aliases) > 0 {
+				content += "Aliases:"
+				for _, alias := range aliases {
+					content += fmt.Sprintf(" `%s`", alias)
+				}
+				content += "\n"
+			}
 			// get files references
 			lLocs, _ := h.Store.LinkStore.GetRefs(id, "")
-			defs, defFound := h.Store.GetDefsFromTarget(target, "")
+			defs, defFound := h.Store.GetDefsFromTarget(ctx, target, "")
 			if len(lLocs) > 0 {
 				content += fmt.Sprintf("%d references found for the file in followings\n", len(lLocs))
 				dMap := map[data.Id]bool{}
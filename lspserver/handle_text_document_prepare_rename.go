@@ -0,0 +1,51 @@
+package lspserver
+
+import (
+	"context"
+	"encoding/json"
+	"sylmark/data"
+	"sylmark/lsp"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+func (h *LangHandler) handleTextDocumentPrepareRename(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params lsp.PrepareRenameParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+	params.TextDocument.URI, _ = data.CleanUpURI(string(params.TextDocument.URI))
+	params.Position = h.decodePosition(params.TextDocument.URI, params.Position)
+
+	id, found := h.Store.FindIdFromURI(params.TextDocument.URI)
+	if !found {
+		return nil, nil
+	}
+	doc, node, ok := h.DocAndNodeFromURIAndPosition(id, params.Position, h.parse)
+	if !ok {
+		return nil, nil
+	}
+
+	switch node.Kind() {
+	case "tag":
+		return lsp.PrepareRenameResult{
+			Range:       lsp.GetRange(node),
+			Placeholder: string(data.GetTag(node, string(doc.Content))),
+		}, nil
+	case "atx_heading":
+		text, rng, ok := data.GetHeadingContent(node, string(doc.Content))
+		if ok {
+			return lsp.PrepareRenameResult{
+				Range:       rng,
+				Placeholder: text,
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
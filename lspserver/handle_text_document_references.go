@@ -9,7 +9,7 @@ import (
 	"github.com/sourcegraph/jsonrpc2"
 )
 
-func (h *LangHandler) handleTextDocumentReferences(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+func (h *LangHandler) handleTextDocumentReferences(ctx context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
 
 	if req.Params == nil {
 		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
@@ -20,8 +20,12 @@ func (h *LangHandler) handleTextDocumentReferences(_ context.Context, _ *jsonrpc
 		return nil, err
 	}
 	params.TextDocument.URI, _ = data.CleanUpURI(string(params.TextDocument.URI))
+	params.Position = h.decodePosition(params.TextDocument.URI, params.Position)
 	uri := params.TextDocument.URI
-	id := h.Store.GetIdFromURI(uri)
+	id, found := h.Store.FindIdFromURI(uri)
+	if !found {
+		return nil, nil
+	}
 
 	doc, node, ok := h.DocAndNodeFromURIAndPosition(id, params.Position, h.parse)
 	content := string(doc.Content)
@@ -32,6 +36,10 @@ func (h *LangHandler) handleTextDocumentReferences(_ context.Context, _ *jsonrpc
 	var locs []lsp.Location
 	var idLocs []data.IdLocation
 
+	if subTarget, _, ok := h.Store.GetBlockIdAt(id, params.Position); ok {
+		return h.Store.GetReferenceLocations(ctx, id, subTarget, h.parse), nil
+	}
+
 	switch node.Kind() {
 	case "tag":
 		{
@@ -105,7 +113,7 @@ func (h *LangHandler) handleTextDocumentReferences(_ context.Context, _ *jsonrpc
 							}
 						}
 					} else {
-						lidLocs, refFound := h.Store.GetRefsFromTarget(target, subTarget)
+						lidLocs, refFound := h.Store.GetRefsFromTarget(ctx, target, subTarget)
 						if refFound {
 							idLocs = append(idLocs, lidLocs...)
 						}
@@ -0,0 +1,51 @@
+package lspserver
+
+import (
+	"context"
+	"encoding/json"
+	"sylmark/data"
+	"sylmark/lsp"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+func (h *LangHandler) handleTextDocumentRename(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params lsp.RenameParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+	params.TextDocument.URI, _ = data.CleanUpURI(string(params.TextDocument.URI))
+	params.Position = h.decodePosition(params.TextDocument.URI, params.Position)
+
+	id, found := h.Store.FindIdFromURI(params.TextDocument.URI)
+	if !found {
+		return nil, nil
+	}
+	doc, node, ok := h.DocAndNodeFromURIAndPosition(id, params.Position, h.parse)
+	if !ok {
+		return nil, nil
+	}
+
+	switch node.Kind() {
+	case "tag":
+		tag := data.GetTag(node, string(doc.Content))
+		edit, err := h.Store.GetTagRenameEdits(tag, params.NewName, h.Store.Config.RenameNestedTags)
+		if err != nil {
+			return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: err.Error()}
+		}
+		return edit, nil
+	case "atx_heading":
+		edit, err := h.Store.GetHeadingRenameEdits(id, node, params.NewName, h.parse)
+		if err != nil {
+			return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: err.Error()}
+		}
+		return edit, nil
+	}
+
+	return nil, nil
+}
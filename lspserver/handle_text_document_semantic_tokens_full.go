@@ -9,7 +9,7 @@ import (
 	"github.com/sourcegraph/jsonrpc2"
 )
 
-func (h *LangHandler) handleTextDocumentSemanticTokensFull(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+func (h *LangHandler) handleTextDocumentSemanticTokensFull(ctx context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
 
 	if req.Params == nil {
 		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
@@ -21,8 +21,11 @@ func (h *LangHandler) handleTextDocumentSemanticTokensFull(_ context.Context, _
 	}
 	params.TextDocument.URI, _ = data.CleanUpURI(string(params.TextDocument.URI))
 
-	id := h.Store.GetIdFromURI(params.TextDocument.URI)
-	tokens := h.Store.GetSemanticTokens(id, h.parse)
+	id, found := h.Store.FindIdFromURI(params.TextDocument.URI)
+	if !found {
+		return nil, nil
+	}
+	tokens := h.Store.GetSemanticTokens(ctx, id, h.parse)
 
 	return tokens, nil
 }
@@ -0,0 +1,23 @@
+package lspserver
+
+import (
+	"context"
+	"encoding/json"
+	"sylmark/lsp"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+func (h *LangHandler) handleWorkspaceDiagnostic(ctx context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params lsp.WorkspaceDiagnosticParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	return h.Store.GetWorkspaceDiagnostics(ctx, params.PreviousResultIds, h.parse), nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"log/slog"
 	"os"
+	"strconv"
 	"sylmark/data"
 	"sylmark/lsp"
 	"sylmark/server"
@@ -14,7 +15,7 @@ import (
 	"github.com/tj/go-naturaldate"
 )
 
-func (h *LangHandler) handleWorkspaceExecuteCommand(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+func (h *LangHandler) handleWorkspaceExecuteCommand(ctx context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
 
 	if req.Params == nil {
 		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
@@ -41,7 +42,7 @@ func (h *LangHandler) handleWorkspaceExecuteCommand(_ context.Context, _ *jsonrp
 				datestring := h.Store.Config.GetMonthDateString(date)
 				fileName := datestring + ".md"
 				subtarget := h.Store.Config.GetMonthDateSubtargetString(date)
-				defs, found := h.Store.GetDefsFromTarget(data.Target(datestring), data.SubTarget("#"+subtarget))
+				defs, found := h.Store.GetDefsFromTarget(ctx, data.Target(datestring), data.SubTarget("#"+subtarget))
 				if found {
 					def := defs[0]
 					uri, _ := h.Store.GetUri(def.Id)
@@ -100,6 +101,70 @@ func (h *LangHandler) handleWorkspaceExecuteCommand(_ context.Context, _ *jsonrp
 				}
 			}
 		}
+	case "mergeTags":
+		{
+			// from, to and optional "nested" to merge #from/child into #to/child as well
+			if len(params.Arguments) < 2 {
+				return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: "mergeTags needs from and to tags"}
+			}
+			from, ok := data.NewTag(params.Arguments[0])
+			if !ok {
+				return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: "Invalid tag " + params.Arguments[0]}
+			}
+			nested := len(params.Arguments) > 2 && params.Arguments[2] == "nested"
+			edit, err := h.Store.GetTagRenameEdits(from, params.Arguments[1], nested)
+			if err != nil {
+				return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: err.Error()}
+			}
+			// client replies to applyEdit only after this request is handled
+			go h.ApplyEdit("Merge tags", encodeWorkspaceEdit(h.Store.NewPositionConverter(), edit))
+		}
+	case "expandEmbed":
+		{
+			// uri, line and character of the embed, sent by code actions
+			if len(params.Arguments) < 3 {
+				return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: "expandEmbed needs uri, line and character"}
+			}
+			uri, _ := data.CleanUpURI(params.Arguments[0])
+			line, lineErr := strconv.Atoi(params.Arguments[1])
+			character, charErr := strconv.Atoi(params.Arguments[2])
+			if lineErr != nil || charErr != nil {
+				return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: "Invalid position"}
+			}
+			pos := h.decodePosition(uri, lsp.Position{Line: line, Character: character})
+			edit, err := h.Store.GetEmbedExpandEdit(ctx, h.Store.GetIdFromURI(uri), pos, h.parse)
+			// params are fine, embed under them can't be expanded
+			if err != nil {
+				return nil, &jsonrpc2.Error{Code: lsp.CodeRequestFailed, Message: err.Error()}
+			}
+			go h.ApplyEdit("Expand embed", encodeWorkspaceEdit(h.Store.NewPositionConverter(), edit))
+		}
+	case "tasks.list":
+		{
+			// filters: open, done, all, overdue, due:<date>, note:<target> and words of text
+			filter, err := data.ParseTaskFilter(params.Arguments, time.Now())
+			if err != nil {
+				return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: err.Error()}
+			}
+			return h.Store.GetTaskItems(filter, time.Now()), nil
+		}
+	case "tasks.toggle":
+		{
+			// uri and line of the task
+			if len(params.Arguments) < 2 {
+				return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: "tasks.toggle needs uri and line"}
+			}
+			uri, _ := data.CleanUpURI(params.Arguments[0])
+			line, err := strconv.Atoi(params.Arguments[1])
+			if err != nil {
+				return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: "Invalid line"}
+			}
+			edit, err := h.Store.GetTaskToggleEdit(h.Store.GetIdFromURI(uri), line)
+			if err != nil {
+				return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: err.Error()}
+			}
+			go h.ApplyEdit("Toggle task", encodeWorkspaceEdit(h.Store.NewPositionConverter(), edit))
+		}
 	case "graph":
 		{
 			server := server.NewServer(&h.Store, &h.Store.Config, h.ShowDocument)
@@ -8,7 +8,7 @@ import (
 	"github.com/sourcegraph/jsonrpc2"
 )
 
-func (h *LangHandler) handleWorkspaceSymbol(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+func (h *LangHandler) handleWorkspaceSymbol(ctx context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
 
 	if req.Params == nil {
 		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
@@ -20,7 +20,7 @@ func (h *LangHandler) handleWorkspaceSymbol(_ context.Context, _ *jsonrpc2.Conn,
 	}
 
 
-	symbols := h.Store.GetAllSymbols(params.Query)
+	symbols := h.Store.GetAllSymbols(ctx, params.Query)
 
 	return symbols, nil
 }
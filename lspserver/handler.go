@@ -7,6 +7,8 @@ import (
 	"sylmark/data"
 	"sylmark/lsp"
 	"sylmark/utils"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sourcegraph/jsonrpc2"
@@ -20,11 +22,16 @@ type ServerDebouncers = struct {
 }
 
 type LangHandler struct {
-	Parser       *tree_sitter.Parser
-	InlineParser *tree_sitter.Parser
-	Store        data.Store
-	Debouncers   *ServerDebouncers
-	Connection   *jsonrpc2.Conn
+	Parser             *tree_sitter.Parser
+	InlineParser       *tree_sitter.Parser
+	Store              data.Store
+	Debouncers         *ServerDebouncers
+	Connection         *jsonrpc2.Conn
+	ClientCapabilities lsp.ClientCapabilities
+	// user is told only about the first crash
+	panicReported atomic.Bool
+	// parsers are shared by requests running concurrently
+	parseMu sync.Mutex
 }
 
 func NewHandler() (hanlder *LangHandler) {
@@ -51,7 +58,7 @@ func (h *LangHandler) loadDocData(mdDocPath string) {
 }
 
 func (h *LangHandler) onDocCreated(id data.Id, content string) {
-	h.onDocOpened(id, content)
+	h.Store.UpdateAndReloadDoc(id, content, nil, h.parse)
 	uri, _ := h.Store.GetUri(id)
 	docPath, _ := data.PathFromURI(uri)
 	h.loadDocData(docPath)
@@ -72,11 +79,30 @@ func (h *LangHandler) onDocDeleted(id data.Id) {
 	}
 }
 func (h *LangHandler) onDocOpened(id data.Id, content string) {
-	h.Store.UpdateAndReloadDoc(id, content, h.parse)
+	h.Store.OpenDoc(id, content, h.parse)
+}
+
+func (h *LangHandler) onDocClosed(uri lsp.DocumentURI) {
+	id := h.Store.GetIdFromURI(uri)
+	h.Store.CloseDoc(id, h.parse)
+}
+
+func (h *LangHandler) onDocSaved(uri lsp.DocumentURI, text *string) {
+	id := h.Store.GetIdFromURI(uri)
+	docData, found := h.Store.DocStore[id]
+	if text == nil || !found || string(docData.Content) == *text {
+		return
+	}
+	h.Store.SyncChangedDocument(id, lsp.TextDocumentContentChangeEvent{Text: *text}, h.parse)
 }
 
 func (h *LangHandler) onDocChanged(uri lsp.DocumentURI, changes lsp.TextDocumentContentChangeEvent) {
 	id := h.Store.GetIdFromURI(uri)
+	// each change is relative to content after the previous one
+	if changes.Range != nil {
+		rng := h.decodeRange(uri, *changes.Range)
+		changes.Range = &rng
+	}
 	h.Store.SyncChangedDocument(id, changes, h.parse)
 }
 
@@ -107,21 +133,27 @@ func (h *LangHandler) SetupGrammars() {
 func (h *LangHandler) DocAndNodeFromURIAndPosition(id data.Id, position lsp.Position, parse lsp.ParseFunction) (docData data.DocumentData, node *tree_sitter.Node, ok bool) {
 	docData, ok = h.Store.GetDocMustTree(id, parse)
 	if !ok {
-		slog.Error("Document missing" + string(id))
+		slog.Error(fmt.Sprintf("Document missing %d", id))
 		return docData, nil, false
 	}
 	point := lsp.PointFromPosition(position)
 
 	node = docData.Trees.GetMainTree().RootNode().NamedDescendantForPointRange(point, point)
+	if node == nil {
+		return docData, nil, false
+	}
 	if node.Kind() == "atx_heading" {
 		return
 	}
-	if node.Parent().Kind() == "atx_heading" {
+	if node.Parent() != nil && node.Parent().Kind() == "atx_heading" {
 		node = node.Parent()
 		return
 	}
 	if lsp.IsInlineParseNeeded(node) {
 		node = docData.Trees.GetInlineTree().RootNode().NamedDescendantForPointRange(point, point)
+		if node == nil {
+			return docData, nil, false
+		}
 	}
 
 	ok = true
@@ -132,59 +164,123 @@ func (h *LangHandler) DocAndNodeFromURIAndPosition(id data.Id, position lsp.Posi
 func getParseFunction(parsers [2]*tree_sitter.Parser) lsp.ParseFunction {
 	return func(content string, oldTrees *lsp.Trees) *lsp.Trees {
 		var trees lsp.Trees
-		trees[0] = parsers[0].Parse([]byte(content), nil)
-		trees[1] = parsers[1].Parse([]byte(content), nil)
+		if oldTrees != nil {
+			trees[0] = parsers[0].Parse([]byte(content), oldTrees.GetMainTree())
+			trees[1] = parsers[1].Parse([]byte(content), oldTrees.GetInlineTree())
+		} else {
+			trees[0] = parsers[0].Parse([]byte(content), nil)
+			trees[1] = parsers[1].Parse([]byte(content), nil)
+		}
 		return &trees
 	}
 }
 
 // update getParseFunction too
 func (h *LangHandler) parse(content string, oldTrees *lsp.Trees) *lsp.Trees {
+	h.parseMu.Lock()
+	defer h.parseMu.Unlock()
 	var trees lsp.Trees
-	trees[0] = h.Parser.Parse([]byte(content), nil)
-	trees[1] = h.InlineParser.Parse([]byte(content), nil)
+	if oldTrees != nil {
+		trees[0] = h.Parser.Parse([]byte(content), oldTrees.GetMainTree())
+		trees[1] = h.InlineParser.Parse([]byte(content), oldTrees.GetInlineTree())
+	} else {
+		trees[0] = h.Parser.Parse([]byte(content), nil)
+		trees[1] = h.InlineParser.Parse([]byte(content), nil)
+	}
 	return &trees
 }
 
 func (h *LangHandler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	readOnly := isReadOnly(req)
+	if readOnly {
+		h.Store.RLock()
+		defer h.Store.RUnlock()
+	} else {
+		h.Store.Lock()
+		defer h.Store.Unlock()
+	}
+	defer h.recoverRequest(req, &result, &err)
+	// cancelled while waiting for the store
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	t := time.Now()
 	switch req.Method {
 	case "initialize":
 		result, err = h.handleInitialize(ctx, conn, req)
 	case "initialized":
+		h.onInitialized()
 	case "shutdown":
 		result, err = h.handleShutdown(ctx, conn, req)
 	case "textDocument/didOpen":
 		result, err = h.handleTextDocumentDidOpen(ctx, conn, req)
-	// case "textDocument/didClose":
-	// 	result,err= h.handleTextDocumentDidClose(ctx, conn, req)
+	case "textDocument/didClose":
+		result, err = h.handleTextDocumentDidClose(ctx, conn, req)
+	case "textDocument/didSave":
+		result, err = h.handleTextDocumentDidSave(ctx, conn, req)
 	case "textDocument/didChange":
 		result, err = h.handleTextDocumentDidChange(ctx, conn, req)
 	case "textDocument/hover":
 		result, err = h.handleHover(ctx, conn, req)
 	case "textDocument/completion":
 		result, err = h.handleTextDocumentCompletion(ctx, conn, req)
+	case "completionItem/resolve":
+		result, err = h.handleCompletionItemResolve(ctx, conn, req)
 	case "textDocument/references":
 		result, err = h.handleTextDocumentReferences(ctx, conn, req)
 	case "textDocument/definition":
 		result, err = h.handleTextDocumentDefinition(ctx, conn, req)
 	case "textDocument/semanticTokens/full":
 		result, err = h.handleTextDocumentSemanticTokensFull(ctx, conn, req)
+	case "textDocument/semanticTokens/full/delta":
+		result, err = h.handleTextDocumentSemanticTokensFullDelta(ctx, conn, req)
+	case "textDocument/semanticTokens/range":
+		result, err = h.handleTextDocumentSemanticTokensRange(ctx, conn, req)
+	case "textDocument/prepareRename":
+		result, err = h.handleTextDocumentPrepareRename(ctx, conn, req)
+	case "textDocument/rename":
+		result, err = h.handleTextDocumentRename(ctx, conn, req)
+	case "textDocument/documentSymbol":
+		result, err = h.handleTextDocumentDocumentSymbol(ctx, conn, req)
+	case "textDocument/foldingRange":
+		result, err = h.handleTextDocumentFoldingRange(ctx, conn, req)
+	case "textDocument/documentLink":
+		result, err = h.handleTextDocumentDocumentLink(ctx, conn, req)
+	case "documentLink/resolve":
+		result, err = h.handleDocumentLinkResolve(ctx, conn, req)
+	case "textDocument/inlayHint":
+		result, err = h.handleTextDocumentInlayHint(ctx, conn, req)
+	case "textDocument/codeLens":
+		result, err = h.handleTextDocumentCodeLens(ctx, conn, req)
 	case "textDocument/codeAction":
 		result, err = h.handleCodeAction(ctx, conn, req)
 	case "textDocument/diagnostic":
 		result, err = h.handleDiagnostics(ctx, conn, req)
+	case "workspace/diagnostic":
+		result, err = h.handleWorkspaceDiagnostic(ctx, conn, req)
 	case "workspace/executeCommand":
 		result, err = h.handleWorkspaceExecuteCommand(ctx, conn, req)
 	case "workspace/didDeleteFiles":
 		result, err = h.handleWorkspaceDidDeleteFiles(ctx, conn, req)
 	case "workspace/didCreateFiles":
 		result, err = h.handleWorkspaceDidCreateFiles(ctx, conn, req)
+	case "workspace/willRenameFiles":
+		result, err = h.handleWorkspaceWillRenameFiles(ctx, conn, req)
+	case "workspace/didChangeWatchedFiles":
+		result, err = h.handleWorkspaceDidChangeWatchedFiles(ctx, conn, req)
 	case "workspace/didRenameFiles":
 		result, err = h.handleWorkspaceDidRenameFiles(ctx, conn, req)
 	case "workspace/symbol":
 		result, err = h.handleWorkspaceSymbol(ctx, conn, req)
 	}
+	if err == nil && result != nil {
+		result = h.encodeResult(getParamsURI(req), result)
+	}
+	if !readOnly {
+		h.refreshDependentDiagnostics()
+		// trees parsed for docs that are not open are not needed anymore, readers may still use them
+		h.Store.ReleaseClosedDocs()
+	}
 	slog.Info(fmt.Sprintf("%dms<==%s", time.Since(t).Milliseconds(), req.Method))
 	return result, err
 }
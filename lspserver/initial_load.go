@@ -39,6 +39,7 @@ func (h *LangHandler) loadAllClosedDocsData() {
 				if err != nil {
 					slog.Error("Some error while parsing " + err.Error())
 					out <- &TreesContent{
+						path:    mdFilePath,
 						uri:     uri,
 						content: content,
 						trees:   nil,
@@ -47,6 +48,7 @@ func (h *LangHandler) loadAllClosedDocsData() {
 					continue
 				}
 				out <- &TreesContent{
+					path:    mdFilePath,
 					uri:     uri,
 					content: content,
 					trees:   trees,
@@ -57,21 +59,43 @@ func (h *LangHandler) loadAllClosedDocsData() {
 	}
 
 	var mdFiles []string
+	infos := map[string]fs.FileInfo{}
+	cache := h.Store.ReadIndexCache()
+	nextCache := h.Store.NewIndexCache()
 
-	// input prepare
-	filepath.WalkDir(h.Store.Config.RootPath, func(path string, d fs.DirEntry, err error) error {
-		if d.IsDir() && (strings.HasSuffix(path, ".") || strings.HasSuffix(path, "node_modules")) {
-			return filepath.SkipDir
+	// input prepare, unchanged files are loaded from cache
+	walkVaultFiles(h.Store.Config.RootPath, func(path string, d fs.DirEntry) {
+		if !data.IsMdFile(path) {
+			h.Store.OtherFiles = append(h.Store.OtherFiles, path)
+			return
+		}
+		info, err := d.Info()
+		if err != nil {
+			return
 		}
-		if !d.IsDir() {
-			if data.IsMdFile(path) {
-				mdFiles = append(mdFiles, path)
-			} else {
-				h.Store.OtherFiles = append(h.Store.OtherFiles, path)
+		if doc, found := cache.Docs[path]; found && doc.IsFresh(info) {
+			uri, err := data.UriFromPath(path)
+			if err == nil {
+				h.Store.LoadCachedData(h.Store.GetIdFromURI(uri), doc)
+				nextCache.Docs[path] = doc
+				return
 			}
 		}
-		return nil
+		infos[path] = info
+		mdFiles = append(mdFiles, path)
 	})
+	defer func() {
+		// files gone since last time are dropped too
+		if len(mdFiles) == 0 && len(nextCache.Docs) == len(cache.Docs) {
+			return
+		}
+		if err := h.Store.WriteIndexCache(nextCache); err != nil {
+			slog.Error(err.Error())
+		}
+	}()
+	if len(mdFiles) == 0 {
+		return
+	}
 
 	// input goroutine
 	go func() {
@@ -86,7 +110,7 @@ func (h *LangHandler) loadAllClosedDocsData() {
 		if val.ok {
 			id := h.Store.GetIdFromURI(val.uri)
 			// utils.Sprintf("jiko id is %d uri was %s", id, val.uri)
-			h.Store.LoadData(id, val.content, val.trees)
+			nextCache.Docs[val.path] = h.Store.LoadDataForCache(id, val.content, val.trees, infos[val.path])
 			// clean up trees
 			val.trees[0].Close()
 			val.trees[1].Close()
@@ -102,6 +126,7 @@ func (h *LangHandler) loadAllClosedDocsData() {
 
 type TreesContent struct {
 	ok      bool
+	path    string
 	uri     lsp.DocumentURI
 	content string
 	trees   *lsp.Trees
@@ -117,3 +142,19 @@ func TreesFromUri(mdDocPath string, parse lsp.ParseFunction) (uri lsp.DocumentUR
 	trees = parse(content, nil)
 	return
 }
+
+// every file of vault skipping dirs like node_modules
+func walkVaultFiles(root string, action func(path string, d fs.DirEntry)) {
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() && (strings.HasSuffix(path, ".") || strings.HasSuffix(path, "node_modules")) {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && d.Name() != data.IndexCacheFileName {
+			action(path, d)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,150 @@
+package lspserver
+
+import (
+	"encoding/json"
+	"sylmark/data"
+	"sylmark/lsp"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// uri of textDocument in params, ranges of results without uri belong to it
+func getParamsURI(req *jsonrpc2.Request) lsp.DocumentURI {
+	if req.Params == nil {
+		return ""
+	}
+	var params struct {
+		TextDocument lsp.TextDocumentIdentifier `json:"textDocument"`
+	}
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return ""
+	}
+	uri, _ := data.CleanUpURI(string(params.TextDocument.URI))
+	return uri
+}
+
+func encodeLocations(c data.PositionConverter, locs []lsp.Location) []lsp.Location {
+	for i, loc := range locs {
+		locs[i].Range = c.FromByteRange(loc.URI, loc.Range)
+	}
+	return locs
+}
+
+func encodeWorkspaceEdit(c data.PositionConverter, edit lsp.WorkspaceEdit) lsp.WorkspaceEdit {
+	for uri, edits := range edit.Changes {
+		for i, e := range edits {
+			edits[i].Range = c.FromByteRange(uri, e.Range)
+		}
+	}
+	return edit
+}
+
+func encodeDiagnostics(c data.PositionConverter, uri lsp.DocumentURI, items []lsp.Diagnostic) []lsp.Diagnostic {
+	for i, item := range items {
+		if item.Range != nil {
+			rng := c.FromByteRange(uri, *item.Range)
+			items[i].Range = &rng
+		}
+	}
+	return items
+}
+
+func encodeDocumentSymbols(c data.PositionConverter, uri lsp.DocumentURI, symbols []lsp.DocumentSymbol) []lsp.DocumentSymbol {
+	for i, symbol := range symbols {
+		symbols[i].Range = c.FromByteRange(uri, symbol.Range)
+		symbols[i].SelectionRange = c.FromByteRange(uri, symbol.SelectionRange)
+		symbols[i].Children = encodeDocumentSymbols(c, uri, symbol.Children)
+	}
+	return symbols
+}
+
+// positions of results are byte columns until here, semantic tokens are encoded by the store itself
+// and code actions only echo diagnostics sent by client
+func (h *LangHandler) encodeResult(uri lsp.DocumentURI, result any) any {
+	if h.Store.PositionEncoding == lsp.PositionEncodingUTF8 {
+		return result
+	}
+	c := h.Store.NewPositionConverter()
+	switch r := result.(type) {
+	case lsp.Hover:
+		if r.Range != nil {
+			rng := c.FromByteRange(uri, *r.Range)
+			r.Range = &rng
+		}
+		return r
+	case []lsp.CompletionItem:
+		for i, item := range r {
+			if item.TextEdit != nil {
+				edit := *item.TextEdit
+				edit.Range = c.FromByteRange(uri, edit.Range)
+				r[i].TextEdit = &edit
+			}
+			for j, edit := range item.AdditionalTextEdits {
+				r[i].AdditionalTextEdits[j].Range = c.FromByteRange(uri, edit.Range)
+			}
+		}
+		return r
+	case lsp.Location:
+		r.Range = c.FromByteRange(r.URI, r.Range)
+		return r
+	case []lsp.Location:
+		return encodeLocations(c, r)
+	case lsp.PrepareRenameResult:
+		r.Range = c.FromByteRange(uri, r.Range)
+		return r
+	case lsp.WorkspaceEdit:
+		return encodeWorkspaceEdit(c, r)
+	case []lsp.DocumentSymbol:
+		return encodeDocumentSymbols(c, uri, r)
+	case []lsp.DocumentLink:
+		for i, link := range r {
+			r[i].Range = c.FromByteRange(uri, link.Range)
+		}
+		return r
+	case []lsp.InlayHint:
+		for i, hint := range r {
+			r[i].Position = c.FromBytePosition(uri, hint.Position)
+		}
+		return r
+	case []lsp.CodeLens:
+		for i, lens := range r {
+			r[i].Range = c.FromByteRange(uri, lens.Range)
+			if lens.Command != nil && lens.Command.Command == data.ShowReferencesCommand {
+				r[i].Command.Arguments[1] = c.FromBytePosition(uri, lens.Command.Arguments[1].(lsp.Position))
+				r[i].Command.Arguments[2] = encodeLocations(c, lens.Command.Arguments[2].([]lsp.Location))
+			}
+		}
+		return r
+	case lsp.DiagnosticResult:
+		r.Items = encodeDiagnostics(c, uri, r.Items)
+		return r
+	case lsp.WorkspaceDiagnosticReport:
+		for i, item := range r.Items {
+			if report, ok := item.(lsp.WorkspaceFullDocumentDiagnosticReport); ok {
+				report.Items = encodeDiagnostics(c, report.URI, report.Items)
+				r.Items[i] = report
+			}
+		}
+		return r
+	case []data.TaskItem:
+		for i, item := range r {
+			r[i].Location.Range = c.FromByteRange(item.Location.URI, item.Location.Range)
+		}
+		return r
+	case []lsp.WorkspaceSymbol:
+		for i, symbol := range r {
+			r[i].Location.Range = c.FromByteRange(symbol.Location.URI, symbol.Location.Range)
+		}
+		return r
+	}
+	return result
+}
+
+// position of params in byte columns
+func (h *LangHandler) decodePosition(uri lsp.DocumentURI, pos lsp.Position) lsp.Position {
+	return h.Store.NewPositionConverter().ToBytePosition(uri, pos)
+}
+
+func (h *LangHandler) decodeRange(uri lsp.DocumentURI, rng lsp.Range) lsp.Range {
+	return h.Store.NewPositionConverter().ToByteRange(uri, rng)
+}
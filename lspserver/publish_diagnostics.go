@@ -13,7 +13,7 @@ func (h *LangHandler) PublishDiagnostics(ctx context.Context, uri lsp.DocumentUR
 		"textDocument/publishDiagnostics",
 		lsp.PublishDiagnosticsParams{
 			URI:         uri,
-			Diagnostics: h.Store.GetDiagnostics(uri, h.parse),
+			Diagnostics: encodeDiagnostics(h.Store.NewPositionConverter(), uri, h.Store.GetDiagnostics(ctx, uri, h.parse)),
 		},
 	)
 }
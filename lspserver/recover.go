@@ -0,0 +1,39 @@
+package lspserver
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sylmark/lsp"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// keeps the server alive when a handler panics, the request fails with InternalError instead
+func (h *LangHandler) recoverRequest(req *jsonrpc2.Request, result *any, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	h.reportPanic(req.Method, r)
+	*result = nil
+	*err = &jsonrpc2.Error{
+		Code:    jsonrpc2.CodeInternalError,
+		Message: fmt.Sprintf("sylmark crashed handling %s: %v", req.Method, r),
+	}
+}
+
+// same as recoverRequest for work started by the server itself
+func (h *LangHandler) recoverTask(task string) {
+	if r := recover(); r != nil {
+		h.reportPanic(task, r)
+	}
+}
+
+func (h *LangHandler) reportPanic(task string, r any) {
+	slog.Error(fmt.Sprintf("panic while handling %s: %v\n%s", task, r, debug.Stack()))
+	if h.Connection != nil && h.panicReported.CompareAndSwap(false, true) {
+		msg := fmt.Sprintf("sylmark hit an internal error handling %s, see the logs for details", task)
+		go h.ShowMessage(lsp.MessageTypeError, msg)
+	}
+}
@@ -0,0 +1,59 @@
+package lspserver
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sylmark/lsp"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+func TestRecoverRequest(t *testing.T) {
+	messages := make(chan lsp.ShowMessageParams, 10)
+	h, _ := newTestConn(t, jsonrpc2.HandlerWithError(func(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+		var params lsp.ShowMessageParams
+		if req.Method == "window/showMessage" && req.Params != nil && json.Unmarshal(*req.Params, &params) == nil {
+			messages <- params
+		}
+		return nil, nil
+	}))
+	handle := func(method string) (result any, err error) {
+		defer h.recoverRequest(&jsonrpc2.Request{Method: method}, &result, &err)
+		result = "partial"
+		panic("boom")
+	}
+
+	t.Run("1 Panic fails request with InternalError", func(t *testing.T) {
+		for _, method := range []string{"textDocument/hover", "textDocument/references"} {
+			result, err := handle(method)
+			if result != nil {
+				t.Errorf("Result of %s >>> nil got %v", method, result)
+			}
+			if getErrorCode(err) != jsonrpc2.CodeInternalError {
+				t.Errorf("Error of %s >>> %d got %v", method, jsonrpc2.CodeInternalError, err)
+			}
+		}
+		func() {
+			defer h.recoverTask("polled file changes")
+			panic("boom")
+		}()
+	})
+	t.Run("2 Only first panic is shown", func(t *testing.T) {
+		select {
+		case msg := <-messages:
+			if msg.Type != lsp.MessageTypeError || !strings.Contains(msg.Message, "textDocument/hover") {
+				t.Errorf("Error about textDocument/hover >>> got %v", msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("No message shown")
+		}
+		select {
+		case msg := <-messages:
+			t.Errorf("Only one message >>> got %s", msg.Message)
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+}
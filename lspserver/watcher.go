@@ -0,0 +1,131 @@
+package lspserver
+
+import (
+	"io/fs"
+	"log/slog"
+	"sylmark/data"
+	"sylmark/lsp"
+	"time"
+)
+
+const pollInterval = 2 * time.Second
+
+// watches vault through client if it can, else polls the root path
+func (h *LangHandler) onInitialized() {
+	if h.Store.Config.RootPath == "" {
+		return
+	}
+	workspace := h.ClientCapabilities.Workspace
+	if workspace != nil && workspace.DidChangeWatchedFiles != nil && workspace.DidChangeWatchedFiles.DynamicRegistration {
+		// client replies only after this request is handled
+		go func() {
+			if err := h.RegisterWatchedFiles(); err != nil {
+				slog.Error(err.Error())
+				h.pollRootPath(h.Store.Config.RootPath, pollInterval)
+			}
+		}()
+		return
+	}
+	go h.pollRootPath(h.Store.Config.RootPath, pollInterval)
+}
+
+func (h *LangHandler) onWatchedFilesChanged(changes []lsp.FileEvent) {
+	var changed, deleted []lsp.DocumentURI
+	for _, change := range changes {
+		uri, _ := data.CleanUpURI(string(change.URI))
+		path, err := data.PathFromURI(uri)
+		if err != nil {
+			continue
+		}
+		if !data.IsMdFile(path) {
+			switch change.Type {
+			case lsp.FileChangeTypeCreated:
+				h.Store.AddOtherFile(path)
+			case lsp.FileChangeTypeDeleted:
+				h.Store.RemoveOtherFile(path)
+			}
+			continue
+		}
+		if change.Type == lsp.FileChangeTypeDeleted {
+			deleted = append(deleted, uri)
+		} else {
+			changed = append(changed, uri)
+		}
+	}
+	h.Store.RemoveDeletedDocs(deleted)
+	h.Store.ReloadChangedDocs(changed, h.parse)
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func snapshotVault(root string) map[string]fileStamp {
+	files := map[string]fileStamp{}
+	walkVaultFiles(root, func(path string, d fs.DirEntry) {
+		info, err := d.Info()
+		if err != nil {
+			return
+		}
+		files[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	})
+	return files
+}
+
+func diffSnapshots(previous map[string]fileStamp, current map[string]fileStamp) (changes []lsp.FileEvent) {
+	add := func(path string, changeType lsp.FileChangeType) {
+		uri, err := data.UriFromPath(path)
+		if err == nil {
+			changes = append(changes, lsp.FileEvent{URI: uri, Type: changeType})
+		}
+	}
+	for path, stamp := range current {
+		old, found := previous[path]
+		if !found {
+			add(path, lsp.FileChangeTypeCreated)
+		} else if old != stamp {
+			add(path, lsp.FileChangeTypeChanged)
+		}
+	}
+	for path := range previous {
+		if _, found := current[path]; !found {
+			add(path, lsp.FileChangeTypeDeleted)
+		}
+	}
+	return changes
+}
+
+// for clients without didChangeWatchedFiles
+func (h *LangHandler) pollRootPath(root string, interval time.Duration) {
+	var done <-chan struct{}
+	if h.Connection != nil {
+		done = h.Connection.DisconnectNotify()
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	previous := snapshotVault(root)
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			current := snapshotVault(root)
+			if changes := diffSnapshots(previous, current); len(changes) > 0 {
+				h.applyPolledChanges(changes)
+			}
+			previous = current
+		}
+	}
+}
+
+// store is unlocked even when handling changes panics, otherwise every request after it would wait forever
+func (h *LangHandler) applyPolledChanges(changes []lsp.FileEvent) {
+	h.Store.Lock()
+	defer h.Store.Unlock()
+	defer h.recoverTask("polled file changes")
+	h.onWatchedFilesChanged(changes)
+	h.refreshDependentDiagnostics()
+	h.Store.ReleaseClosedDocs()
+}
@@ -0,0 +1,60 @@
+package lspserver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sylmark/lsp"
+	"testing"
+	"time"
+)
+
+func TestPolledChanges(t *testing.T) {
+	root := t.TempDir()
+	write := func(name string, content string) {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// changes as "name type" sorted
+	diff := func(previous, current map[string]fileStamp) []string {
+		var got []string
+		for _, change := range diffSnapshots(previous, current) {
+			got = append(got, fmt.Sprintf("%s %d", filepath.Base(string(change.URI)), change.Type))
+		}
+		slices.Sort(got)
+		return got
+	}
+	write("a.md", "a")
+	write("b.md", "b")
+	previous := snapshotVault(root)
+
+	write("a.md", "changed")
+	write("c.md", "c")
+	if err := os.Remove(filepath.Join(root, "b.md")); err != nil {
+		t.Fatal(err)
+	}
+	current := snapshotVault(root)
+	want := []string{
+		fmt.Sprintf("a.md %d", lsp.FileChangeTypeChanged),
+		fmt.Sprintf("b.md %d", lsp.FileChangeTypeDeleted),
+		fmt.Sprintf("c.md %d", lsp.FileChangeTypeCreated),
+	}
+	if got := diff(previous, current); !slices.Equal(got, want) {
+		t.Errorf("Changes >>> %v got %v", want, got)
+	}
+	if got := diff(current, current); len(got) != 0 {
+		t.Errorf("Unchanged >>> [] got %v", got)
+	}
+
+	t.Run("1 Same size with new mod time", func(t *testing.T) {
+		path := filepath.Join(root, "c.md")
+		if err := os.Chtimes(path, time.Now(), time.Now().Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := diff(current, snapshotVault(root)), []string{fmt.Sprintf("c.md %d", lsp.FileChangeTypeChanged)}; !slices.Equal(got, want) {
+			t.Errorf("Changes >>> %v got %v", want, got)
+		}
+	})
+}
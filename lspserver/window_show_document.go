@@ -12,7 +12,7 @@ func (h *LangHandler) ShowDocument(uri lsp.DocumentURI, external bool, rng lsp.R
 		lsp.ShowDocumentParams{
 			URI:       uri,
 			External:  external,
-			Selection: rng,
+			Selection: h.Store.NewPositionConverter().FromByteRange(uri, rng),
 			TakeFocus: true,
 		},
 	)
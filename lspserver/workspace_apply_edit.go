@@ -0,0 +1,29 @@
+package lspserver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sylmark/lsp"
+)
+
+func (h *LangHandler) ApplyEdit(label string, edit lsp.WorkspaceEdit) error {
+
+	result := lsp.ApplyWorkspaceEditResult{}
+	err := h.Connection.Call(context.Background(), "workspace/applyEdit",
+		lsp.ApplyWorkspaceEditParams{
+			Label: label,
+			Edit:  edit,
+		},
+		&result,
+	)
+	if err != nil {
+		slog.Error("failed to call workspace/applyEdit " + err.Error())
+		return fmt.Errorf("failed to call workspace/applyEdit: %w", err)
+	}
+	if !result.Applied {
+		slog.Error("client failed to apply edit " + result.FailureReason)
+		return fmt.Errorf("client failed to apply edit: %s", result.FailureReason)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package lspserver
+
+import (
+	"context"
+	"log/slog"
+)
+
+// open notes linking to changed files or headings get fresh diagnostics,
+// pull clients are asked to refresh and others get them published
+func (h *LangHandler) refreshDependentDiagnostics() {
+	uris := h.Store.TakeDependentDocs()
+	if len(uris) == 0 || h.Connection == nil {
+		return
+	}
+
+	workspace := h.ClientCapabilities.Workspace
+	if workspace != nil && workspace.Diagnostics != nil && workspace.Diagnostics.RefreshSupport {
+		// client replies only after the request being handled is done
+		go func() {
+			err := h.Connection.Call(context.Background(), "workspace/diagnostic/refresh", nil, nil)
+			if err != nil {
+				slog.Error("failed to call workspace/diagnostic/refresh " + err.Error())
+			}
+		}()
+		return
+	}
+	for _, uri := range uris {
+		h.PublishDiagnostics(context.Background(), uri)
+	}
+}
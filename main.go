@@ -21,8 +21,7 @@ func main() {
 	handler := lspserver.NewHandler()
 	handler.SetupGrammars()
 	defer handler.Parser.Close()
-	jsonHandler := jsonrpc2.HandlerWithError(handler.Handle)
-	conn := jsonrpc2.NewConn(ctx, stream, jsonHandler)
+	conn := jsonrpc2.NewConn(ctx, stream, lspserver.NewAsyncHandler(handler))
 	handler.Connection = conn
 	<-conn.DisconnectNotify()
 
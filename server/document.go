@@ -18,11 +18,17 @@ func (server *Server) ShowDocument(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	server.mu.Lock()
 	node, found := server.graphStore.nodeStore.get(NodeId(py.Id))
-	if found {
-		uri, ok := server.store.GetUri(node.InternalId)
-		if ok {
-			server.showDocument(uri, false, lsp.Range{})
-		}
+	server.mu.Unlock()
+	if !found {
+		return
+	}
+	// store is only locked for the lookup, showing writes to the client connection
+	server.store.RLock()
+	uri, ok := server.store.GetUri(node.InternalId)
+	server.store.RUnlock()
+	if ok {
+		server.showDocument(uri, false, lsp.Range{})
 	}
 }
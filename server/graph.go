@@ -26,10 +26,12 @@ func (server *Server) GetGraph(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	s := server
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	// to refresh the data
 	s.graphStore = newGraphStore()
-	s.LoadGraph()
+	s.loadGraphLocked()
 
 	g := newGraph()
 	gs := s.graphStore
@@ -51,6 +53,13 @@ func (server *Server) GetGraph(w http.ResponseWriter, r *http.Request) {
 	WriteJson(g, w)
 }
 
+// read lock is released even when loading panics, lsp handler would wait for it forever
+func (server *Server) loadGraphLocked() {
+	server.store.RLock()
+	defer server.store.RUnlock()
+	server.LoadGraph()
+}
+
 // returns 1, 2, 3
 func getSize(connections int, maxCon int, minCon int) int {
 
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"sylmark/data"
 	"sylmark/lsp"
+	"sync"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -17,6 +18,9 @@ type Server struct {
 	rootPath     string
 	Config       *data.Config
 	showDocument lsp.ShowDocumentFx
+
+	// guards graphStore between concurrent http requests, store has it's own lock
+	mu sync.Mutex
 }
 
 func NewServer(store *data.Store, config *data.Config, showDocument lsp.ShowDocumentFx) (server *Server) {
@@ -89,8 +89,9 @@ func newGraphStore() *GraphStore {
 	}
 }
 
+// caller holds read lock of store
 func (server *Server) LoadGraph() {
-	if server == nil && server.graphStore != nil {
+	if server == nil || server.graphStore == nil || server.store == nil {
 		slog.Error("GraphStore is nil")
 		return
 	}
@@ -123,6 +124,9 @@ func (server *Server) LoadGraph() {
 	}
 	// add shadows
 	for id, tars := range s.store.IdStore.ShadowTargets {
+		if len(tars) == 0 {
+			continue
+		}
 		var t data.Target
 		if len(tars) == 1 {
 			t = tars[0]
@@ -156,8 +160,10 @@ func (server *Server) LoadGraph() {
 			},
 		)
 		for _, l := range refs {
-			id := s.store.GetIdFromURI(l.URI)
-			s.graphStore.linkStore.add(nodeId, NodeId(id))
+			id, found := s.store.FindIdFromURI(l.URI)
+			if found {
+				s.graphStore.linkStore.add(nodeId, NodeId(id))
+			}
 		}
 	}
 